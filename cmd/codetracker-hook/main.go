@@ -0,0 +1,11 @@
+package main
+
+import (
+	"os"
+
+	"codetracker-hooks/internal/hooks"
+)
+
+func main() {
+	hooks.Main(os.Args)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"codetracker-hooks/internal/config"
+)
+
+// runConfig prints the effective configuration and where each value came from,
+// or the config JSON Schema with "config schema"
+func runConfig(args []string) int {
+	if len(args) > 0 && args[0] == "schema" {
+		data, err := json.MarshalIndent(config.Schema(), "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "codetracker: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+
+	cfg, sources, err := config.LoadConfigWithSources()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "codetracker: %v\n", err)
+		return 1
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "codetracker: %v\n", err)
+		return 1
+	}
+	var effective map[string]interface{}
+	if err := json.Unmarshal(data, &effective); err != nil {
+		fmt.Fprintf(os.Stderr, "codetracker: %v\n", err)
+		return 1
+	}
+
+	for _, key := range sources.Keys() {
+		val, ok := lookup(effective, key)
+		if !ok {
+			fmt.Printf("%s (unknown key)  [%s]\n", key, sources[key])
+			continue
+		}
+		encoded, _ := json.Marshal(val)
+		fmt.Printf("%s = %s  [%s]\n", key, encoded, sources[key])
+	}
+
+	return 0
+}
+
+// lookup finds a dotted key in a nested JSON object
+func lookup(m map[string]interface{}, dottedKey string) (interface{}, bool) {
+	var node interface{} = m
+	for _, part := range strings.Split(dottedKey, ".") {
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		node, ok = obj[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return node, true
+}
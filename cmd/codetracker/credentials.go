@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"codetracker-hooks/internal/cache"
+	"codetracker-hooks/internal/config"
+)
+
+// runCredentials shows where credentials come from, or migrates the API key
+// out of the project with "credentials migrate"
+func runCredentials(args []string) int {
+	cfg, _ := config.LoadConfig()
+
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := migrateCredentials(); err != nil {
+			fmt.Fprintf(os.Stderr, "codetracker: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Moved API keys to %s\n", config.UserCredentialsFile())
+		return 0
+	}
+
+	creds, err := config.LoadCredentials(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "codetracker: %v\n", err)
+	}
+	if creds == nil {
+		return 1
+	}
+	applyProjectIdentity(creds)
+
+	fields := []struct{ name, value string }{
+		{"api_key", maskSecret(creds.APIKey)},
+		{"current_project_hash", creds.CurrentProjectHash},
+		{"username", creds.Username},
+		{"email", creds.Email},
+	}
+	for _, f := range fields {
+		source := creds.Sources[f.name]
+		if source == "" {
+			source = "not set"
+		}
+		fmt.Printf("%-22s %-24s [%s]\n", f.name, f.value, source)
+	}
+
+	if len(creds.Projects) > 0 {
+		fmt.Printf("projects [%s]\n", creds.Sources["projects"])
+		for _, p := range creds.Projects {
+			match := "path_prefix=" + p.PathPrefix
+			if p.RemoteURL != "" {
+				match = "remote_url=" + p.RemoteURL
+				if p.PathPrefix != "" {
+					match += " path_prefix=" + p.PathPrefix
+				}
+			}
+			key := "default api_key"
+			if p.APIKey != "" {
+				key = "api_key " + maskSecret(p.APIKey)
+			} else if k := creds.ProjectKeys[p.ProjectHash]; k != "" {
+				key = "project_keys " + maskSecret(k) + " [" + creds.Sources["project_keys."+p.ProjectHash] + "]"
+			}
+			fmt.Printf("  %-40s -> %s (%s)\n", match, p.ProjectHash, key)
+		}
+	}
+
+	if !creds.IsValid() {
+		return 1
+	}
+	return 0
+}
+
+// applyProjectIdentity fills in the project hash the hooks registered for the
+// git repository when none is configured
+func applyProjectIdentity(creds *config.Credentials) {
+	if creds.CurrentProjectHash != "" {
+		return
+	}
+	identity, err := cache.LoadProjectIdentity(config.ProjectIdentityFile())
+	if err != nil || identity.ProjectHash == "" {
+		return
+	}
+	creds.CurrentProjectHash = identity.ProjectHash
+	creds.Sources["current_project_hash"] = config.SourceGitIdentity
+}
+
+// maskSecret hides all but the last four characters of a secret
+func maskSecret(secret string) string {
+	if len(secret) <= 4 {
+		return secret
+	}
+	return "****" + secret[len(secret)-4:]
+}
+
+// migrateCredentials moves the API keys and account fields from the project
+// credentials.json into the user-level file (mode 0600). Keys of project
+// mappings move to project_keys. The project hash and project mappings stay in
+// the project file since they describe the project, not the user.
+func migrateCredentials() error {
+	data, err := os.ReadFile(config.CredentialsFile())
+	if err != nil {
+		return err
+	}
+	var project config.Credentials
+	if err := json.Unmarshal(data, &project); err != nil {
+		return err
+	}
+	mappingKeys := map[string]string{}
+	for i := range project.Projects {
+		p := &project.Projects[i]
+		if p.APIKey == "" {
+			continue
+		}
+		if p.ProjectHash == "" {
+			return fmt.Errorf("projects[%d] has an api_key but no project_hash", i)
+		}
+		if other, ok := mappingKeys[p.ProjectHash]; ok && other != p.APIKey {
+			return fmt.Errorf("project %s has different API keys in several mappings", p.ProjectHash)
+		}
+		mappingKeys[p.ProjectHash] = p.APIKey
+		p.APIKey = ""
+	}
+	if project.APIKey == "" && len(mappingKeys) == 0 {
+		return errors.New("no API key in " + config.CredentialsFile())
+	}
+
+	userFile := config.UserCredentialsFile()
+	if userFile == "" {
+		return errors.New("cannot determine the user config directory")
+	}
+
+	var user config.Credentials
+	if existing, err := os.ReadFile(userFile); err == nil {
+		if err := json.Unmarshal(existing, &user); err != nil {
+			return fmt.Errorf("%s: %w", userFile, err)
+		}
+	}
+	if project.APIKey != "" {
+		user.APIKey = project.APIKey
+	}
+	for hash, key := range mappingKeys {
+		if user.ProjectKeys == nil {
+			user.ProjectKeys = map[string]string{}
+		}
+		user.ProjectKeys[hash] = key
+	}
+	if user.Username == "" {
+		user.Username = project.Username
+	}
+	if user.Email == "" {
+		user.Email = project.Email
+	}
+
+	if err := os.MkdirAll(filepath.Dir(userFile), 0700); err != nil {
+		return err
+	}
+	userData, err := json.MarshalIndent(&user, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(userFile, userData, 0600); err != nil {
+		return err
+	}
+	if err := os.Chmod(userFile, 0600); err != nil {
+		return err
+	}
+
+	remaining, err := json.MarshalIndent(&config.Credentials{CurrentProjectHash: project.CurrentProjectHash, Projects: project.Projects}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(config.CredentialsFile(), remaining, 0644)
+}
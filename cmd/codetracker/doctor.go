@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+
+	"codetracker-hooks/internal/config"
+	"codetracker-hooks/internal/git"
+)
+
+// runDoctor validates the configuration and credentials and reports problems
+func runDoctor(args []string) int {
+	cfg, issues := config.Validate()
+
+	creds, err := config.LoadCredentials(cfg)
+	if creds != nil {
+		applyProjectIdentity(creds)
+	}
+	switch {
+	case err != nil:
+		issues = append(issues, config.Issue{Severity: config.SeverityError, Key: "credentials", Message: err.Error()})
+	case creds.APIKey != "" && creds.PrimaryTarget("").ProjectHash == "" && isGitRepo():
+		issues = append(issues, config.Issue{Severity: config.SeverityWarning, Key: "credentials.current_project_hash", Message: "not set; the project will be registered from the git repository on the next prompt"})
+	case !creds.IsValid():
+		issues = append(issues, config.Issue{Severity: config.SeverityError, Key: "credentials", Message: "api_key and current_project_hash (or projects) are required"})
+	}
+	if creds != nil && creds.Sources["api_key"] == config.ProviderProjectFile {
+		issues = append(issues, config.Issue{
+			Severity: config.SeverityWarning,
+			Key:      "credentials.api_key",
+			Source:   config.CredentialsFile(),
+			Message:  "API key is stored inside the project (deprecated); run 'codetracker credentials migrate'",
+		})
+	}
+
+	if creds != nil {
+		issues = append(issues, projectIssues(creds)...)
+	}
+
+	if len(issues) == 0 {
+		fmt.Println("No problems found.")
+		return 0
+	}
+
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+	if config.HasErrors(issues) {
+		return 1
+	}
+	return 0
+}
+
+// projectIssues checks the project mappings in the credentials
+func projectIssues(creds *config.Credentials) []config.Issue {
+	var issues []config.Issue
+	source := creds.Sources["projects"]
+
+	for i, p := range creds.Projects {
+		key := fmt.Sprintf("credentials.projects[%d]", i)
+		if p.ProjectHash == "" {
+			issues = append(issues, config.Issue{Severity: config.SeverityError, Key: key, Source: source, Message: "project_hash is required"})
+		}
+		if p.PathPrefix == "" && p.RemoteURL == "" {
+			issues = append(issues, config.Issue{Severity: config.SeverityError, Key: key, Source: source, Message: "path_prefix or remote_url is required"})
+		}
+		if p.APIKey == "" && creds.ProjectKeys[p.ProjectHash] == "" && creds.APIKey == "" {
+			issues = append(issues, config.Issue{Severity: config.SeverityError, Key: key, Source: source, Message: "no api_key for this project and no default api_key"})
+		}
+		if p.APIKey != "" && source == config.ProviderProjectFile {
+			issues = append(issues, config.Issue{
+				Severity: config.SeverityWarning,
+				Key:      key + ".api_key",
+				Source:   config.CredentialsFile(),
+				Message:  "API key is stored inside the project; use the user credentials file or a credential helper",
+			})
+		}
+	}
+	return issues
+}
+
+// isGitRepo reports whether the project root is inside a git repository
+func isGitRepo() bool {
+	_, err := git.Open(config.GetProjectRoot())
+	return err == nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// command is a codetracker subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var commands = []*command{
+	{name: "revert", summary: "Undo the changes of a single interaction", run: runRevert},
+	{name: "config", summary: "Show the effective configuration and its sources", run: runConfig},
+	{name: "credentials", summary: "Show credential sources or migrate the API key out of the project", run: runCredentials},
+	{name: "doctor", summary: "Check configuration and credentials for problems", run: runDoctor},
+	{name: "transcript", summary: "Validate a Claude Code transcript file", run: runTranscript},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			os.Exit(cmd.run(os.Args[2:]))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command: %s\n\n", os.Args[1])
+	usage()
+	os.Exit(2)
+}
+
+// usage prints the list of available commands
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: codetracker <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"codetracker-hooks/internal/cache"
+	"codetracker-hooks/internal/config"
+	"codetracker-hooks/internal/revert"
+)
+
+// runRevert reverts the changes of one interaction, keeping later edits
+func runRevert(args []string) int {
+	fs := flag.NewFlagSet("revert", flag.ContinueOnError)
+	dryRun := fs.Bool("n", false, "show what would be reverted without writing files")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: codetracker revert [-n] [pre-snapshot-id|last]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	id := "last"
+	if fs.NArg() > 0 {
+		id = fs.Arg(0)
+	}
+
+	record, err := findInteraction(id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "codetracker: %v\n", err)
+		return 1
+	}
+
+	results := revert.Interaction(record, revert.Options{
+		ProjectRoot: config.GetProjectRoot(),
+		ObjectsDir:  config.ObjectsDir(),
+		DryRun:      *dryRun,
+	})
+
+	fmt.Printf("Reverting interaction %s: %s\n", record.PreSnapshotID, record.Prompt)
+	exitCode := 0
+	for _, r := range results {
+		line := fmt.Sprintf("  %-16s %s", r.Status, r.FilePath)
+		if r.Conflicts > 0 {
+			line += fmt.Sprintf(" (%d conflicts)", r.Conflicts)
+		}
+		if r.Message != "" {
+			line += " - " + r.Message
+		}
+		fmt.Println(line)
+
+		if r.Status == revert.Conflict || r.Status == revert.Failed {
+			exitCode = 1
+		}
+	}
+
+	return exitCode
+}
+
+// findInteraction loads an interaction record by pre snapshot ID, or the most recent one
+func findInteraction(id string) (*cache.InteractionRecord, error) {
+	if id != "last" {
+		return cache.LoadInteraction(config.InteractionsDir(), id)
+	}
+
+	records, err := cache.ListInteractions(config.InteractionsDir(), 1)
+	if err != nil || len(records) == 0 {
+		return nil, fmt.Errorf("no recorded interactions")
+	}
+	return records[0], nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"codetracker-hooks/internal/transcript"
+)
+
+// runTranscript checks a Claude Code transcript file with "transcript validate <file>"
+func runTranscript(args []string) int {
+	if len(args) != 2 || args[0] != "validate" {
+		fmt.Fprintln(os.Stderr, "Usage: codetracker transcript validate <file>")
+		return 2
+	}
+
+	health, err := transcript.Validate(args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "codetracker: %v\n", err)
+		return 1
+	}
+
+	for _, pe := range health.Errors {
+		fmt.Printf("line %d (offset %d): %s\n", pe.Line, pe.Offset, pe.Err)
+	}
+	if more := health.Malformed - len(health.Errors); more > 0 {
+		fmt.Printf("... and %d more malformed lines\n", more)
+	}
+	if health.Incomplete {
+		fmt.Println("last line has no newline (still being written, or truncated)")
+	}
+
+	fmt.Printf("%d lines, %d malformed\n", health.Lines, health.Malformed)
+	if health.Malformed > 0 {
+		return 1
+	}
+	return 0
+}
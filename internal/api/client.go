@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"codetracker-hooks/internal/diff"
+	"codetracker-hooks/internal/git"
 )
 
 // FlexibleID handles both string and number JSON values
@@ -106,6 +107,12 @@ type CreateSnapshotRequest struct {
 	Changes          []*diff.Change `json:"changes"`
 	ClaudeSessionID  string         `json:"claude_session_id,omitempty"`
 	ParentSnapshotID string         `json:"parent_snapshot_id,omitempty"`
+	ToolName         string         `json:"tool_name,omitempty"`
+	ToolUseID        string         `json:"tool_use_id,omitempty"`
+	Git              *git.Metadata  `json:"git,omitempty"`
+	// Labels and Ticket classify the prompt the snapshot belongs to
+	Labels []string `json:"labels,omitempty"`
+	Ticket string   `json:"ticket,omitempty"`
 }
 
 // CreateSnapshotResponse is the response from creating a snapshot
@@ -129,7 +136,9 @@ func (c *Client) CreateSnapshot(req *CreateSnapshotRequest) (*CreateSnapshotResp
 	return &resp, nil
 }
 
-// CreateInteractionRequest is the request body for creating an interaction
+// CreateInteractionRequest is the request body for creating an interaction.
+// Subagent interactions set ParentInteractionID to the pre snapshot ID of the
+// prompt that launched them, so the server can build a tree per prompt.
 type CreateInteractionRequest struct {
 	ProjectHash         string         `json:"project_hash"`
 	Message             string         `json:"message"`
@@ -140,6 +149,17 @@ type CreateInteractionRequest struct {
 	EndedAt             string         `json:"ended_at"`
 	ConversationStartID *int64         `json:"conversation_start_id,omitempty"`
 	ConversationEndID   *int64         `json:"conversation_end_id,omitempty"`
+	ParentInteractionID string         `json:"parent_interaction_id,omitempty"`
+	AgentID             string         `json:"agent_id,omitempty"`
+	Git                 *git.Metadata  `json:"git,omitempty"`
+	// Labels and Ticket classify the prompt the interaction belongs to
+	Labels []string `json:"labels,omitempty"`
+	Ticket string   `json:"ticket,omitempty"`
+
+	// Token totals and estimated cost of the turn's assistant responses
+	Usage *InteractionUsage `json:"usage,omitempty"`
+	// Assistant responses of the turn, when conversation_tracking.include_assistant_metadata is on
+	AssistantMessages []AssistantMessage `json:"assistant_messages,omitempty"`
 }
 
 // CreateInteractionResponse is the response from creating an interaction
@@ -162,10 +182,77 @@ func (c *Client) CreateInteraction(req *CreateInteractionRequest) (*CreateIntera
 	return &resp, nil
 }
 
-// ConversationEntry represents a filtered conversation entry
+// ConversationEntry represents a filtered conversation entry.
+// EntryType is "user", "assistant" or "tool_use"; tool_use entries carry Tool.
 type ConversationEntry struct {
-	EntryType string `json:"entry_type"`
-	EntryData string `json:"entry_data"`
+	EntryType string    `json:"entry_type"`
+	EntryData string    `json:"entry_data"`
+	Tool      *ToolCall `json:"tool,omitempty"`
+	// Images and documents of a user entry, also shown as placeholders in EntryData
+	Attachments []Attachment `json:"attachments,omitempty"`
+
+	// Transcript entry identity, so the server can deduplicate and rebuild
+	// branches; tool_use entries share the UUID of the entry they came from
+	UUID       string `json:"uuid,omitempty"`
+	ParentUUID string `json:"parent_uuid,omitempty"`
+	Timestamp  string `json:"timestamp,omitempty"`
+	Model      string `json:"model,omitempty"`
+}
+
+// Attachment describes an image or document in a user message
+type Attachment struct {
+	Type      string `json:"type"` // "image" or "document"
+	MediaType string `json:"media_type,omitempty"`
+	Size      int    `json:"size"`
+	// AttachmentID is set when the image was uploaded with UploadAttachment
+	AttachmentID string `json:"attachment_id,omitempty"`
+}
+
+// ToolCall describes a tool invocation made by the assistant and its result
+type ToolCall struct {
+	ToolUseID       string   `json:"tool_use_id"`
+	Name            string   `json:"name"`
+	InputSummary    string   `json:"input_summary"`
+	FilePaths       []string `json:"file_paths,omitempty"`
+	Status          string   `json:"status"`
+	ExitCode        *int     `json:"exit_code,omitempty"`
+	Output          string   `json:"output,omitempty"`
+	OutputTruncated bool     `json:"output_truncated,omitempty"`
+}
+
+// Tool call statuses
+const (
+	ToolStatusPending = "pending"
+	ToolStatusOK      = "ok"
+	ToolStatusError   = "error"
+)
+
+// AssistantMessage describes one assistant API response within an interaction
+type AssistantMessage struct {
+	MessageID         string      `json:"message_id"`
+	UUID              string      `json:"uuid,omitempty"`
+	Model             string      `json:"model,omitempty"`
+	StopReason        string      `json:"stop_reason,omitempty"`
+	Usage             *TokenUsage `json:"usage,omitempty"`
+	Thinking          string      `json:"thinking,omitempty"`
+	ThinkingTruncated bool        `json:"thinking_truncated,omitempty"`
+}
+
+// TokenUsage is the token usage reported for an assistant message
+type TokenUsage struct {
+	InputTokens              int64 `json:"input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+}
+
+// InteractionUsage totals the token usage of an interaction's assistant responses
+type InteractionUsage struct {
+	TokenUsage
+	Messages int `json:"messages"`
+	// EstimatedCostUSD is nil when no response's model has a configured price
+	EstimatedCostUSD *float64 `json:"estimated_cost_usd,omitempty"`
+	UnpricedModels   []string `json:"unpriced_models,omitempty"`
 }
 
 // SendConversationsRequest is the request body for sending conversation entries
@@ -173,6 +260,22 @@ type SendConversationsRequest struct {
 	ProjectHash string              `json:"project_hash"`
 	SessionID   string              `json:"session_id"`
 	Entries     []ConversationEntry `json:"entries"`
+	// Sent with the first page of a turn
+	TranscriptHealth *TranscriptHealth `json:"transcript_health,omitempty"`
+}
+
+// TranscriptHealth reports the transcript lines read for a turn and the
+// malformed ones, which were skipped
+type TranscriptHealth struct {
+	LinesRead int                    `json:"lines_read"`
+	Malformed int                    `json:"malformed"`
+	Errors    []TranscriptParseError `json:"errors,omitempty"`
+}
+
+// TranscriptParseError locates a malformed transcript line
+type TranscriptParseError struct {
+	Offset int64  `json:"offset"`
+	Error  string `json:"error"`
 }
 
 // SendConversationsResponse is the response from sending conversations
@@ -197,3 +300,119 @@ func (c *Client) SendConversations(req *SendConversationsRequest) (*SendConversa
 
 	return &resp, nil
 }
+
+// UploadAttachmentRequest is the request body for uploading an image from a user message
+type UploadAttachmentRequest struct {
+	ProjectHash string `json:"project_hash"`
+	SessionID   string `json:"session_id"`
+	UUID        string `json:"uuid,omitempty"`
+	MediaType   string `json:"media_type"`
+	Data        string `json:"data"` // base64
+}
+
+// UploadAttachmentResponse is the response from uploading an attachment
+type UploadAttachmentResponse struct {
+	AttachmentID FlexibleID `json:"attachment_id"`
+}
+
+// UploadAttachment uploads an image so conversation entries can reference it
+func (c *Client) UploadAttachment(req *UploadAttachmentRequest) (*UploadAttachmentResponse, error) {
+	respBody, err := c.doRequest("POST", "/api/attachments", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp UploadAttachmentResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// StartSessionRequest is the request body for opening a session record
+type StartSessionRequest struct {
+	ProjectHash     string `json:"project_hash"`
+	ClaudeSessionID string `json:"claude_session_id"`
+	Source          string `json:"source,omitempty"`
+	StartedAt       string `json:"started_at"`
+}
+
+// EndSessionRequest is the request body for closing a session record
+type EndSessionRequest struct {
+	ProjectHash     string `json:"project_hash"`
+	ClaudeSessionID string `json:"claude_session_id"`
+	Reason          string `json:"reason,omitempty"`
+	EndedAt         string `json:"ended_at"`
+}
+
+// StartSession opens a server-side session record
+func (c *Client) StartSession(req *StartSessionRequest) error {
+	_, err := c.doRequest("POST", "/api/sessions", req)
+	return err
+}
+
+// EndSession closes a server-side session record
+func (c *Client) EndSession(req *EndSessionRequest) error {
+	_, err := c.doRequest("POST", "/api/sessions/end", req)
+	return err
+}
+
+// RegisterProjectRequest is the request body for mapping a git-derived identity to a project
+type RegisterProjectRequest struct {
+	Identity   string `json:"identity"`
+	RemoteURL  string `json:"remote_url,omitempty"`
+	RootCommit string `json:"root_commit"`
+	Name       string `json:"name,omitempty"`
+}
+
+// RegisterProjectResponse is the response from registering a project
+type RegisterProjectResponse struct {
+	ProjectHash string `json:"project_hash"`
+}
+
+// RegisterProject returns the project for an identity, creating it on first use
+func (c *Client) RegisterProject(req *RegisterProjectRequest) (*RegisterProjectResponse, error) {
+	respBody, err := c.doRequest("POST", "/api/projects/register", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp RegisterProjectResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, err
+	}
+	if resp.ProjectHash == "" {
+		return nil, fmt.Errorf("register project: no project_hash in response")
+	}
+
+	return &resp, nil
+}
+
+// CommitInteractionLink is the overlap between a commit and one interaction
+type CommitInteractionLink struct {
+	SnapshotID     string   `json:"snapshot_id"`
+	PreSnapshotID  string   `json:"pre_snapshot_id"`
+	Files          []string `json:"files"`
+	AssistantLines int      `json:"assistant_lines"`
+	FileOverlap    float64  `json:"file_overlap"`
+	LineOverlap    float64  `json:"line_overlap"`
+}
+
+// CommitLinksRequest is the request body for linking a commit to the interactions it contains
+type CommitLinksRequest struct {
+	ProjectHash    string                  `json:"project_hash"`
+	CommitSHA      string                  `json:"commit_sha"`
+	ParentSHAs     []string                `json:"parent_shas,omitempty"`
+	Branch         string                  `json:"branch,omitempty"`
+	FilesChanged   int                     `json:"files_changed"`
+	LinesAdded     int                     `json:"lines_added"`
+	AssistantLines int                     `json:"assistant_lines"`
+	Interactions   []CommitInteractionLink `json:"interactions"`
+}
+
+// SendCommitLinks reports which interactions a commit contains
+func (c *Client) SendCommitLinks(req *CommitLinksRequest) error {
+	_, err := c.doRequest("POST", "/api/commits/links", req)
+	return err
+}
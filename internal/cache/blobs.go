@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"codetracker-hooks/internal/scanner"
+)
+
+// blobPath returns the path of a content-addressed blob inside objectsDir
+func blobPath(objectsDir, hash string) string {
+	if len(hash) < 3 {
+		return filepath.Join(objectsDir, hash)
+	}
+	return filepath.Join(objectsDir, hash[:2], hash[2:])
+}
+
+// SaveBlobs stores the content of scanned files keyed by their hash.
+// Blobs that already exist are left untouched.
+func SaveBlobs(objectsDir string, files map[string]*scanner.FileInfo) error {
+	for _, info := range files {
+		path := blobPath(objectsDir, info.Hash)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(info.Content), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadBlob loads stored file content by hash
+func LoadBlob(objectsDir, hash string) (string, error) {
+	data, err := os.ReadFile(blobPath(objectsDir, hash))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// CollectBlobs removes blobs whose hash is not in keep. Blobs written within
+// grace are kept too, since a running hook may not have referenced them yet.
+// It returns the number of blobs removed.
+func CollectBlobs(objectsDir string, keep map[string]bool, grace time.Duration, now time.Time) (int, error) {
+	dirs, err := os.ReadDir(objectsDir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		dirPath := filepath.Join(objectsDir, dir.Name())
+		blobs, err := os.ReadDir(dirPath)
+		if err != nil {
+			continue
+		}
+
+		left := len(blobs)
+		for _, blob := range blobs {
+			if keep[dir.Name()+blob.Name()] {
+				continue
+			}
+			info, err := blob.Info()
+			if err != nil || now.Sub(info.ModTime()) < grace {
+				continue
+			}
+			if os.Remove(filepath.Join(dirPath, blob.Name())) == nil {
+				removed++
+				left--
+			}
+		}
+		if left == 0 {
+			os.Remove(dirPath)
+		}
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"codetracker-hooks/internal/git"
+)
+
+// ProjectIdentity caches the project hash the server registered for a git
+// identity, or the failed attempts to register it
+type ProjectIdentity struct {
+	Identity    *git.Identity `json:"identity"`
+	ServerURL   string        `json:"server_url"`
+	ProjectHash string        `json:"project_hash"`
+
+	// Failures counts consecutive failed registrations, the last at FailedAt
+	Failures  int    `json:"failures,omitempty"`
+	FailedAt  string `json:"failed_at,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// LoadProjectIdentity loads the cached project identity
+func LoadProjectIdentity(cacheFile string) (*ProjectIdentity, error) {
+	data, err := os.ReadFile(cacheFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var identity ProjectIdentity
+	if err := json.Unmarshal(data, &identity); err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// SaveProjectIdentity saves the project identity to cache
+func SaveProjectIdentity(cacheFile string, identity *ProjectIdentity) error {
+	if err := os.MkdirAll(filepath.Dir(cacheFile), 0755); err != nil {
+		return err
+	}
+
+	jsonData, err := json.MarshalIndent(identity, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cacheFile, jsonData, 0644)
+}
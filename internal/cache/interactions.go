@@ -0,0 +1,207 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"codetracker-hooks/internal/diff"
+)
+
+// InteractionRecord holds the local record of one prompt-response interaction
+type InteractionRecord struct {
+	SnapshotID      string         `json:"snapshot_id"`
+	PreSnapshotID   string         `json:"pre_snapshot_id"`
+	Prompt          string         `json:"prompt"`
+	ClaudeSessionID string         `json:"claude_session_id"`
+	StartedAt       string         `json:"started_at"`
+	EndedAt         string         `json:"ended_at"`
+	Changes         []*diff.Change `json:"changes"`
+}
+
+// indexFile is the name of the interaction index inside the interactions directory
+const indexFile = "index.json"
+
+// IndexEntry locates one interaction record in the index
+type IndexEntry struct {
+	PreSnapshotID string `json:"pre_snapshot_id"`
+	EndedAt       string `json:"ended_at"`
+}
+
+// SaveInteraction saves an interaction record keyed by its pre snapshot ID
+// and adds it to the index. File contents are dropped; they are kept in the
+// blob store instead.
+func SaveInteraction(interactionsDir string, record *InteractionRecord) error {
+	if record.PreSnapshotID == "" {
+		return errors.New("interaction record has no pre snapshot ID")
+	}
+	if err := os.MkdirAll(interactionsDir, 0755); err != nil {
+		return err
+	}
+
+	stripped := *record
+	stripped.Changes = make([]*diff.Change, 0, len(record.Changes))
+	for _, c := range record.Changes {
+		change := *c
+		change.Content = ""
+		stripped.Changes = append(stripped.Changes, &change)
+	}
+
+	jsonData, err := json.MarshalIndent(&stripped, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(recordPath(interactionsDir, record.PreSnapshotID), jsonData, 0644); err != nil {
+		return err
+	}
+
+	index, err := loadIndex(interactionsDir)
+	if err != nil {
+		return err
+	}
+	kept := index[:0]
+	for _, entry := range index {
+		if entry.PreSnapshotID != record.PreSnapshotID {
+			kept = append(kept, entry)
+		}
+	}
+	kept = append(kept, IndexEntry{PreSnapshotID: record.PreSnapshotID, EndedAt: record.EndedAt})
+	return saveIndex(interactionsDir, kept)
+}
+
+// recordPath returns the path of an interaction record file
+func recordPath(interactionsDir, preSnapshotID string) string {
+	return filepath.Join(interactionsDir, preSnapshotID+".json")
+}
+
+// LoadInteraction loads an interaction record by its pre snapshot ID
+func LoadInteraction(interactionsDir, preSnapshotID string) (*InteractionRecord, error) {
+	data, err := os.ReadFile(recordPath(interactionsDir, preSnapshotID))
+	if err != nil {
+		return nil, err
+	}
+
+	var record InteractionRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// ListInteractions loads up to limit interaction records, most recent first.
+// A limit of 0 or less loads all of them.
+func ListInteractions(interactionsDir string, limit int) ([]*InteractionRecord, error) {
+	index, err := loadIndex(interactionsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*InteractionRecord
+	for _, entry := range index {
+		if limit > 0 && len(records) >= limit {
+			break
+		}
+		record, err := LoadInteraction(interactionsDir, entry.PreSnapshotID)
+		if err != nil {
+			// Skip unreadable records
+			continue
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// PruneInteractions removes interaction records beyond the newest maxCount
+// and those that ended more than maxAge ago. Zero disables either limit.
+// It returns the number of records removed.
+func PruneInteractions(interactionsDir string, maxCount int, maxAge time.Duration, now time.Time) (int, error) {
+	index, err := loadIndex(interactionsDir)
+	if err != nil || len(index) == 0 {
+		return 0, err
+	}
+
+	var kept, removed []IndexEntry
+	for _, entry := range index {
+		expired := false
+		if maxAge > 0 {
+			if ended, err := time.Parse(time.RFC3339, entry.EndedAt); err == nil {
+				expired = now.Sub(ended) > maxAge
+			}
+		}
+		if expired || (maxCount > 0 && len(kept) >= maxCount) {
+			removed = append(removed, entry)
+		} else {
+			kept = append(kept, entry)
+		}
+	}
+	if len(removed) == 0 {
+		return 0, nil
+	}
+
+	for _, entry := range removed {
+		os.Remove(recordPath(interactionsDir, entry.PreSnapshotID))
+	}
+	return len(removed), saveIndex(interactionsDir, kept)
+}
+
+// loadIndex reads the interaction index, newest first. A missing or
+// unreadable index is rebuilt from the records in the directory.
+func loadIndex(interactionsDir string) ([]IndexEntry, error) {
+	data, err := os.ReadFile(filepath.Join(interactionsDir, indexFile))
+	if err == nil {
+		var index []IndexEntry
+		if err := json.Unmarshal(data, &index); err == nil {
+			return index, nil
+		}
+	}
+	return rebuildIndex(interactionsDir)
+}
+
+// rebuildIndex indexes every readable record in the directory, for caches
+// written before the index existed
+func rebuildIndex(interactionsDir string) ([]IndexEntry, error) {
+	dirEntries, err := os.ReadDir(interactionsDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var index []IndexEntry
+	for _, e := range dirEntries {
+		if e.IsDir() || e.Name() == indexFile || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		record, err := LoadInteraction(interactionsDir, strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil || record.PreSnapshotID == "" {
+			continue
+		}
+		index = append(index, IndexEntry{PreSnapshotID: record.PreSnapshotID, EndedAt: record.EndedAt})
+	}
+	if len(index) == 0 {
+		return nil, nil
+	}
+
+	return index, saveIndex(interactionsDir, index)
+}
+
+// saveIndex sorts the index newest first and writes it
+func saveIndex(interactionsDir string, index []IndexEntry) error {
+	sort.SliceStable(index, func(i, j int) bool {
+		return index[i].EndedAt > index[j].EndedAt
+	})
+
+	jsonData, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(interactionsDir, indexFile), jsonData, 0644)
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPruneInteractions(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	saved := []struct {
+		id    string
+		ended time.Time
+	}{
+		{"old", now.AddDate(0, 0, -40)},
+		{"week", now.AddDate(0, 0, -7)},
+		{"day", now.AddDate(0, 0, -1)},
+		{"hour", now.Add(-time.Hour)},
+	}
+
+	tests := []struct {
+		name     string
+		maxCount int
+		maxAge   time.Duration
+		want     []string
+	}{
+		{"no limits", 0, 0, []string{"hour", "day", "week", "old"}},
+		{"by count", 2, 0, []string{"hour", "day"}},
+		{"by age", 0, 30 * 24 * time.Hour, []string{"hour", "day", "week"}},
+		{"count and age", 3, 2 * 24 * time.Hour, []string{"hour", "day"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, s := range saved {
+				record := &InteractionRecord{PreSnapshotID: s.id, EndedAt: s.ended.Format(time.RFC3339)}
+				if err := SaveInteraction(dir, record); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			removed, err := PruneInteractions(dir, tt.maxCount, tt.maxAge, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if removed != len(saved)-len(tt.want) {
+				t.Errorf("removed %d, want %d", removed, len(saved)-len(tt.want))
+			}
+
+			records, err := ListInteractions(dir, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range records {
+				got = append(got, r.PreSnapshotID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("kept %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("kept %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestListInteractionsRebuildsIndex(t *testing.T) {
+	dir := t.TempDir()
+	for id, ended := range map[string]string{"a": "2026-10-01T00:00:00Z", "b": "2026-10-02T00:00:00Z", "c": "2026-10-03T00:00:00Z"} {
+		if err := SaveInteraction(dir, &InteractionRecord{PreSnapshotID: id, EndedAt: ended}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Caches written before the index existed have only the records
+	if err := os.Remove(filepath.Join(dir, indexFile)); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := ListInteractions(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].PreSnapshotID != "c" || records[1].PreSnapshotID != "b" {
+		t.Errorf("got %d records, want c and b", len(records))
+	}
+}
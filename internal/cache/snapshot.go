@@ -9,10 +9,29 @@ import (
 	"codetracker-hooks/internal/scanner"
 )
 
-// TranscriptState holds the state of transcript synchronization
+// TranscriptState holds the state of transcript synchronization: a cursor
+// just past the last consumed entry. States without ByteOffset come from older
+// versions and only carry LastLineCount.
 type TranscriptState struct {
 	SessionID     string `json:"session_id"`
 	LastLineCount int    `json:"last_line_count"`
+
+	// ByteOffset is where the next unread line starts
+	ByteOffset int64 `json:"byte_offset,omitempty"`
+	// LastEntryOffset is where the last consumed line starts
+	LastEntryOffset int64 `json:"last_entry_offset,omitempty"`
+	// LastEntryHash identifies the last consumed entry (hash of its uuid)
+	LastEntryHash string `json:"last_entry_hash,omitempty"`
+	// RecentHashes identifies recently consumed entries, oldest first, to
+	// find the last common entry when the transcript was rewritten
+	RecentHashes []string `json:"recent_hashes,omitempty"`
+
+	// Unsent marks a cursor left before entries that stop could not upload;
+	// user_prompt_submit keeps it so the next stop sends them
+	Unsent bool `json:"unsent,omitempty"`
+	// Attachments maps the images already uploaded for unsent entries, by
+	// hash of the entry uuid and image data, to their attachment IDs
+	Attachments map[string]string `json:"attachments,omitempty"`
 }
 
 // CachedSnapshot holds the last snapshot state
@@ -20,6 +39,12 @@ type CachedSnapshot struct {
 	SnapshotID string                            `json:"snapshot_id,omitempty"`
 	Files      map[string]*diff.SnapshotFileInfo `json:"files"`
 	Transcript *TranscriptState                  `json:"transcript,omitempty"`
+
+	// ProjectSnapshotIDs holds the last snapshot ID of each secondary project, by project hash
+	ProjectSnapshotIDs map[string]string `json:"project_snapshot_ids,omitempty"`
+
+	// Sources holds, in tool checkpoints, the source of each file changed so far in the prompt
+	Sources map[string]*diff.Attribution `json:"sources,omitempty"`
 }
 
 // LoadLastSnapshot loads the last snapshot from cache file
@@ -49,6 +74,15 @@ func SaveLastSnapshot(cacheFile string, files map[string]*scanner.FileInfo, snap
 
 // SaveLastSnapshotWithTranscript saves the current file state and transcript state to cache
 func SaveLastSnapshotWithTranscript(cacheFile string, files map[string]*scanner.FileInfo, snapshotID string, transcript *TranscriptState) error {
+	return SaveCachedSnapshot(cacheFile, files, &CachedSnapshot{
+		SnapshotID: snapshotID,
+		Transcript: transcript,
+	})
+}
+
+// SaveCachedSnapshot saves the current file state along with the IDs and
+// transcript state in snapshot to cache
+func SaveCachedSnapshot(cacheFile string, files map[string]*scanner.FileInfo, snapshot *CachedSnapshot) error {
 	// Ensure directory exists
 	dir := filepath.Dir(cacheFile)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -64,11 +98,7 @@ func SaveLastSnapshotWithTranscript(cacheFile string, files map[string]*scanner.
 		}
 	}
 
-	snapshot := &CachedSnapshot{
-		SnapshotID: snapshotID,
-		Files:      snapshotFiles,
-		Transcript: transcript,
-	}
+	snapshot.Files = snapshotFiles
 
 	jsonData, err := json.MarshalIndent(snapshot, "", "  ")
 	if err != nil {
@@ -1,8 +1,9 @@
 package config
 
 import (
-	"encoding/json"
-	"os"
+	"fmt"
+	"regexp"
+	"strings"
 )
 
 // AutoSnapshot holds auto-snapshot configuration
@@ -17,42 +18,200 @@ type AutoSnapshot struct {
 type ConversationTracking struct {
 	Enabled              bool `json:"enabled"`
 	MaxEntriesPerRequest int  `json:"max_entries_per_request"`
+	IncludeToolUse       bool `json:"include_tool_use"`
+	MaxToolOutputBytes   int  `json:"max_tool_output_bytes"`
+
+	// Per-response model, stop reason and token usage sent with the interaction
+	IncludeAssistantMetadata bool `json:"include_assistant_metadata"`
+	// Also send thinking text, cut at MaxThinkingBytes
+	IncludeThinking  bool `json:"include_thinking"`
+	MaxThinkingBytes int  `json:"max_thinking_bytes"`
+
+	// Upload inline images of user messages up to MaxImageBytes
+	UploadImages  bool `json:"upload_images"`
+	MaxImageBytes int  `json:"max_image_bytes"`
 }
 
-// Config holds the configuration from config.json
-type Config struct {
-	Version              string               `json:"version"`
-	ServerURL            string               `json:"server_url"`
-	IgnorePatterns       []string             `json:"ignore_patterns"`
-	TrackExtensions      []string             `json:"track_extensions"`
-	MaxFileSize          int64                `json:"max_file_size"`
-	AutoSnapshot         AutoSnapshot         `json:"auto_snapshot"`
-	ConversationTracking ConversationTracking `json:"conversation_tracking"`
+// ToolTracking holds per-tool snapshot configuration for PreToolUse/PostToolUse hooks
+type ToolTracking struct {
+	Enabled bool     `json:"enabled"`
+	Tools   []string `json:"tools"`
 }
 
-// LoadConfig loads and parses config.json
-func LoadConfig() (*Config, error) {
-	data, err := os.ReadFile(ConfigFile())
-	if err != nil {
-		return nil, err
+// defaultTrackedTools are the tools snapshotted when ToolTracking.Tools is empty
+var defaultTrackedTools = []string{"Edit", "MultiEdit", "Write", "NotebookEdit", "Bash"}
+
+// Tracks reports whether snapshots should be taken around the given tool
+func (t ToolTracking) Tracks(toolName string) bool {
+	if !t.Enabled {
+		return false
+	}
+	tools := t.Tools
+	if len(tools) == 0 {
+		tools = defaultTrackedTools
+	}
+	for _, name := range tools {
+		if name == toolName || name == "*" {
+			return true
+		}
 	}
+	return false
+}
+
+// SessionTracking holds configuration for SessionStart/SessionEnd hooks
+type SessionTracking struct {
+	Enabled bool `json:"enabled"`
+}
+
+// ContextInjection holds configuration for returning tracking context to Claude
+type ContextInjection struct {
+	Enabled  bool `json:"enabled"`
+	MaxFiles int  `json:"max_files"`
+}
+
+// History holds retention limits for the local interaction records and blob
+// store used by revert and commit linking. Zero disables a limit.
+type History struct {
+	MaxInteractions int `json:"max_interactions"`
+	MaxAgeDays      int `json:"max_age_days"`
+}
+
+// PolicyRule declares a guardrail evaluated by the user_prompt_submit and stop hooks
+type PolicyRule struct {
+	Name     string   `json:"name,omitempty"`
+	Type     string   `json:"type"`
+	Events   []string `json:"events,omitempty"`
+	Paths    []string `json:"paths,omitempty"`
+	MaxFiles int      `json:"max_files,omitempty"`
+	Patterns []string `json:"patterns,omitempty"`
+	Message  string   `json:"message,omitempty"`
+}
+
+// TagRule attaches a label to prompts matching any of its conditions
+type TagRule struct {
+	Label    string   `json:"label"`
+	Patterns []string `json:"patterns,omitempty"` // case-insensitive regular expressions
+	Keywords []string `json:"keywords,omitempty"` // case-insensitive whole words
+	Commands []string `json:"commands,omitempty"` // slash commands the prompt starts with, e.g. "/test"
+
+	// Regexps are the compiled keywords and patterns, set by Tagging.Compile
+	Regexps []*regexp.Regexp `json:"-"`
+}
+
+// Tagging holds prompt classification configuration for user_prompt_submit
+type Tagging struct {
+	Rules []TagRule `json:"rules"`
+	// TicketPattern extracts a ticket ID from the prompt, e.g. `[A-Z]+-\d+`;
+	// with a capture group, the first group is used
+	TicketPattern string `json:"ticket_pattern"`
+
+	// Ticket is the compiled TicketPattern, set by Compile
+	Ticket *regexp.Regexp `json:"-"`
+
+	invalid []patternError
+}
 
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, err
+// patternError is an invalid regular expression and the config key it came from
+type patternError struct {
+	key string
+	err error
+}
+
+// Compile compiles the keywords and patterns of the tag rules and the ticket
+// pattern. It runs when the config loads; invalid patterns are left out, so
+// they never match, and are reported by Check.
+func (t *Tagging) Compile() {
+	t.invalid = nil
+	for i := range t.Rules {
+		rule := &t.Rules[i]
+		rule.Regexps = nil
+		for _, keyword := range rule.Keywords {
+			if keyword != "" {
+				rule.Regexps = append(rule.Regexps, regexp.MustCompile(`(?i)(?:^|\W)`+regexp.QuoteMeta(keyword)+`(?:\W|$)`))
+			}
+		}
+		for j, pattern := range rule.Patterns {
+			re, err := regexp.Compile("(?i)" + pattern)
+			if err != nil {
+				t.invalid = append(t.invalid, patternError{fmt.Sprintf("tagging.rules[%d].patterns[%d]", i, j), err})
+				continue
+			}
+			rule.Regexps = append(rule.Regexps, re)
+		}
 	}
 
-	// Set defaults
-	if config.MaxFileSize == 0 {
-		config.MaxFileSize = 1024 * 1024 // 1MB default
+	t.Ticket = nil
+	if t.TicketPattern != "" {
+		re, err := regexp.Compile(t.TicketPattern)
+		if err != nil {
+			t.invalid = append(t.invalid, patternError{"tagging.ticket_pattern", err})
+		}
+		t.Ticket = re
 	}
-	if config.ServerURL == "" {
-		config.ServerURL = "http://localhost:5000"
+}
+
+// ModelPrice is the price of a model in USD per million tokens
+type ModelPrice struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheWrite float64 `json:"cache_write"`
+	CacheRead  float64 `json:"cache_read"`
+}
+
+// PriceFor returns the price of a model from the pricing table. Keys match
+// the model ID or a prefix of it; the longest matching key wins.
+func (c *Config) PriceFor(model string) (ModelPrice, bool) {
+	best, found := "", false
+	for key := range c.Pricing {
+		if strings.HasPrefix(model, key) && (!found || len(key) > len(best)) {
+			best, found = key, true
+		}
 	}
-	// ConversationTracking defaults: enabled by default with max 100 entries
-	if config.ConversationTracking.MaxEntriesPerRequest == 0 {
-		config.ConversationTracking.MaxEntriesPerRequest = 100
+	return c.Pricing[best], found
+}
+
+// Config holds the configuration from config.json
+type Config struct {
+	Version              string                `json:"version"`
+	ServerURL            string                `json:"server_url"`
+	IgnorePatterns       []string              `json:"ignore_patterns"`
+	TrackExtensions      []string              `json:"track_extensions"`
+	MaxFileSize          int64                 `json:"max_file_size"`
+	AutoSnapshot         AutoSnapshot          `json:"auto_snapshot"`
+	ConversationTracking ConversationTracking  `json:"conversation_tracking"`
+	ToolTracking         ToolTracking          `json:"tool_tracking"`
+	SessionTracking      SessionTracking       `json:"session_tracking"`
+	ContextInjection     ContextInjection      `json:"context_injection"`
+	History              History               `json:"history"`
+	Policies             []PolicyRule          `json:"policies"`
+	Tagging              Tagging               `json:"tagging"`
+	CredentialHelper     string                `json:"credential_helper"`
+	Pricing              map[string]ModelPrice `json:"pricing"`
+}
+
+// defaultConfig returns the built-in defaults, the lowest configuration layer
+func defaultConfig() *Config {
+	return &Config{
+		ServerURL:   "http://localhost:5000",
+		MaxFileSize: 1024 * 1024, // 1MB
+		ConversationTracking: ConversationTracking{
+			MaxEntriesPerRequest: 100,
+			MaxToolOutputBytes:   2000,
+			MaxThinkingBytes:     4000,
+			MaxImageBytes:        5 * 1024 * 1024, // 5MB
+		},
+		ContextInjection: ContextInjection{
+			MaxFiles: 20,
+		},
+		History: History{
+			MaxInteractions: 200,
+			MaxAgeDays:      30,
+		},
 	}
+}
 
-	return &config, nil
+// LoadConfig loads the effective configuration from all layers
+func LoadConfig() (*Config, error) {
+	config, _, err := LoadConfigWithSources()
+	return config, err
 }
@@ -1,34 +1,171 @@
 package config
 
 import (
-	"encoding/json"
-	"os"
+	"errors"
+	"fmt"
+	"strings"
+
+	"codetracker-hooks/internal/git"
 )
 
-// Credentials holds the credentials from credentials.json
+// Credentials holds the API key and project identity used by the hooks
 type Credentials struct {
-	APIKey             string `json:"api_key"`
-	Username           string `json:"username"`
-	Email              string `json:"email"`
-	CurrentProjectHash string `json:"current_project_hash"`
+	APIKey             string `json:"api_key,omitempty"`
+	Username           string `json:"username,omitempty"`
+	Email              string `json:"email,omitempty"`
+	CurrentProjectHash string `json:"current_project_hash,omitempty"`
+
+	// Projects maps path prefixes or git remotes to projects, for monorepos
+	// and multiple accounts
+	Projects []ProjectCredentials `json:"projects,omitempty"`
+
+	// ProjectKeys holds API keys by project hash for mappings without their
+	// own api_key, so the user file can keep keys out of the project
+	ProjectKeys map[string]string `json:"project_keys,omitempty"`
+
+	// Sources maps each field's JSON name to the provider that supplied it
+	Sources map[string]string `json:"-"`
 }
 
-// LoadCredentials loads and parses credentials.json
-func LoadCredentials() (*Credentials, error) {
-	data, err := os.ReadFile(CredentialsFile())
-	if err != nil {
-		return nil, err
+// ProjectCredentials maps part of the tree, or a whole repository by its
+// remote URL, to a project. APIKey defaults to the top-level API key.
+type ProjectCredentials struct {
+	PathPrefix  string `json:"path_prefix,omitempty"`
+	RemoteURL   string `json:"remote_url,omitempty"`
+	ProjectHash string `json:"project_hash"`
+	APIKey      string `json:"api_key,omitempty"`
+}
+
+// ProjectTarget is a project hash together with the API key used to write to it
+type ProjectTarget struct {
+	ProjectHash string
+	APIKey      string
+}
+
+// LoadCredentials resolves credentials from the default provider chain
+func LoadCredentials(cfg *Config) (*Credentials, error) {
+	return LoadCredentialsFrom(DefaultProviders(cfg))
+}
+
+// LoadCredentialsFrom merges credentials field by field; the first provider
+// supplying a field wins. Provider errors are returned only if no valid
+// credentials could be assembled.
+func LoadCredentialsFrom(providers []CredentialProvider) (*Credentials, error) {
+	creds := &Credentials{Sources: map[string]string{}}
+	var errs []string
+
+	for _, p := range providers {
+		c, err := p.Load()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", p.Name(), err))
+			continue
+		}
+		if c == nil {
+			continue
+		}
+		creds.merge(c, p.Name())
 	}
 
-	var creds Credentials
-	if err := json.Unmarshal(data, &creds); err != nil {
-		return nil, err
+	if !creds.IsValid() && len(errs) > 0 {
+		return creds, errors.New(strings.Join(errs, "; "))
 	}
+	return creds, nil
+}
 
-	return &creds, nil
+// merge fills empty fields from other, recording their source
+func (c *Credentials) merge(other *Credentials, source string) {
+	fill := func(dst *string, val, field string) {
+		if *dst == "" && val != "" {
+			*dst = val
+			c.Sources[field] = source
+		}
+	}
+	fill(&c.APIKey, other.APIKey, "api_key")
+	fill(&c.Username, other.Username, "username")
+	fill(&c.Email, other.Email, "email")
+	fill(&c.CurrentProjectHash, other.CurrentProjectHash, "current_project_hash")
+	if len(c.Projects) == 0 && len(other.Projects) > 0 {
+		c.Projects = other.Projects
+		c.Sources["projects"] = source
+	}
+	for hash, key := range other.ProjectKeys {
+		if c.ProjectKeys == nil {
+			c.ProjectKeys = map[string]string{}
+		}
+		if c.ProjectKeys[hash] == "" && key != "" {
+			c.ProjectKeys[hash] = key
+			c.Sources["project_keys."+hash] = source
+		}
+	}
 }
 
 // IsValid checks if credentials have required fields
 func (c *Credentials) IsValid() bool {
-	return c.APIKey != "" && c.CurrentProjectHash != ""
+	return c.PrimaryTarget("").ProjectHash != "" && c.PrimaryTarget("").APIKey != ""
+}
+
+// target returns the ProjectTarget of a mapping, falling back to the key in
+// project_keys and then the top-level API key
+func (c *Credentials) target(p *ProjectCredentials) ProjectTarget {
+	key := p.APIKey
+	if key == "" {
+		key = c.keyFor(p.ProjectHash)
+	}
+	return ProjectTarget{ProjectHash: p.ProjectHash, APIKey: key}
+}
+
+// keyFor returns the API key for a project hash from project_keys, or the top-level API key
+func (c *Credentials) keyFor(projectHash string) string {
+	if key := c.ProjectKeys[projectHash]; key != "" {
+		return key
+	}
+	return c.APIKey
+}
+
+// PrimaryTarget returns the project that owns the session and conversation:
+// a remote-only mapping matching remoteURL, else current_project_hash, else
+// the first mapping in projects
+func (c *Credentials) PrimaryTarget(remoteURL string) ProjectTarget {
+	normalized := git.NormalizeURL(remoteURL)
+	for i := range c.Projects {
+		p := &c.Projects[i]
+		if p.PathPrefix == "" && p.RemoteURL != "" && normalized != "" && git.NormalizeURL(p.RemoteURL) == normalized {
+			return c.target(p)
+		}
+	}
+
+	if c.CurrentProjectHash != "" {
+		return ProjectTarget{ProjectHash: c.CurrentProjectHash, APIKey: c.keyFor(c.CurrentProjectHash)}
+	}
+	if len(c.Projects) > 0 {
+		return c.target(&c.Projects[0])
+	}
+	return ProjectTarget{APIKey: c.APIKey}
+}
+
+// TargetFor returns the project for a slash-separated path relative to the
+// project root: the mapping with the longest matching path_prefix (and
+// matching remote_url, if set), else the primary target
+func (c *Credentials) TargetFor(relPath, remoteURL string) ProjectTarget {
+	normalized := git.NormalizeURL(remoteURL)
+	best, bestLen := -1, -1
+
+	for i := range c.Projects {
+		p := &c.Projects[i]
+		if p.PathPrefix == "" {
+			continue
+		}
+		if p.RemoteURL != "" && git.NormalizeURL(p.RemoteURL) != normalized {
+			continue
+		}
+		prefix := strings.Trim(p.PathPrefix, "/")
+		if (relPath == prefix || strings.HasPrefix(relPath, prefix+"/")) && len(prefix) > bestLen {
+			best, bestLen = i, len(prefix)
+		}
+	}
+
+	if best >= 0 {
+		return c.target(&c.Projects[best])
+	}
+	return c.PrimaryTarget(remoteURL)
 }
@@ -0,0 +1,315 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SourceDefault marks values coming from the built-in defaults
+const SourceDefault = "default"
+
+// envPrefix is the prefix of environment variables overriding config keys
+const envPrefix = "CODETRACKER_"
+
+// userOnlyKeys are keys ignored in the project config file. A cloned
+// repository must not be able to name a command to run, or a server that
+// would receive the user's API key.
+var userOnlyKeys = []string{"credential_helper", "server_url"}
+
+// defaultWhenZero are keys where 0 (or "" for server_url) in a layer means
+// unset, so the value of the layer below stays in effect
+var defaultWhenZero = map[string]bool{
+	"server_url":    true,
+	"max_file_size": true,
+	"conversation_tracking.max_entries_per_request": true,
+	"conversation_tracking.max_tool_output_bytes":   true,
+	"conversation_tracking.max_thinking_bytes":      true,
+	"conversation_tracking.max_image_bytes":         true,
+	"context_injection.max_files":                   true,
+}
+
+// Sources maps dotted config keys (e.g. "auto_snapshot.enabled") to the
+// layer that set them: "default", a config file path, or "env:NAME"
+type Sources map[string]string
+
+// LoadConfigWithSources resolves the configuration by merging, in order,
+// built-in defaults, the user config file, the project config file and
+// CODETRACKER_* environment variables. Objects merge key by key; arrays and
+// scalars from a later layer replace earlier ones.
+func LoadConfigWithSources() (*Config, Sources, error) {
+	config, sources, err := resolve()
+	if err != nil {
+		return nil, nil, err
+	}
+	return config, sources, nil
+}
+
+// resolve merges all layers. On a type mismatch it still returns the
+// partially decoded config along with the error, for validation.
+func resolve() (*Config, Sources, error) {
+	merged, err := toMap(defaultConfig())
+	if err != nil {
+		return nil, nil, err
+	}
+	sources := Sources{}
+	recordSources(merged, "", SourceDefault, sources)
+
+	for _, path := range []string{UserConfigFile(), ConfigFile()} {
+		layer, err := readLayer(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if path == ConfigFile() {
+			for _, key := range userOnlyKeys {
+				delete(layer, key)
+			}
+		}
+		dropZeroValues(layer)
+		mergeLayer(merged, layer, "", path, sources)
+	}
+
+	envLayer, err := envOverrides(sources)
+	if err != nil {
+		return nil, nil, err
+	}
+	mergeLayer(merged, envLayer, "", "", nil)
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, nil, err
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			config.Tagging.Compile()
+			return &config, sources, err
+		}
+		return nil, nil, err
+	}
+
+	config.Tagging.Compile()
+	return &config, sources, nil
+}
+
+// readLayer reads one config file as a generic JSON object; a missing file is an empty layer
+func readLayer(path string) (map[string]interface{}, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var layer map[string]interface{}
+	if err := json.Unmarshal(data, &layer); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return layer, nil
+}
+
+// dropZeroValues removes the defaultWhenZero keys holding a zero value from a layer
+func dropZeroValues(layer map[string]interface{}) {
+	for dotted := range defaultWhenZero {
+		parts := strings.Split(dotted, ".")
+		node := layer
+		for _, part := range parts[:len(parts)-1] {
+			node, _ = node[part].(map[string]interface{})
+		}
+		last := parts[len(parts)-1]
+		if node != nil && isZeroDefault(dotted, node[last]) {
+			delete(node, last)
+		}
+	}
+}
+
+// isZeroDefault reports whether val leaves a defaultWhenZero key unset
+func isZeroDefault(dotted string, val interface{}) bool {
+	if !defaultWhenZero[dotted] {
+		return false
+	}
+	switch v := val.(type) {
+	case float64:
+		return v == 0
+	case int64:
+		return v == 0
+	case string:
+		return v == ""
+	}
+	return false
+}
+
+// toMap converts a config struct into a generic JSON object
+func toMap(config *Config) (map[string]interface{}, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+// mergeLayer merges src into dst, recording the source of every replaced leaf
+func mergeLayer(dst, src map[string]interface{}, prefix, source string, sources Sources) {
+	for key, val := range src {
+		path := joinKey(prefix, key)
+		srcMap, srcIsMap := val.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeLayer(dstMap, srcMap, path, source, sources)
+			continue
+		}
+
+		dst[key] = val
+		if sources != nil {
+			recordSources(val, path, source, sources)
+		}
+	}
+}
+
+// recordSources marks every leaf under val as coming from source
+func recordSources(val interface{}, path, source string, sources Sources) {
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		// Replacing a leaf drops sources recorded for keys below it
+		for key := range sources {
+			if strings.HasPrefix(key, path+".") {
+				delete(sources, key)
+			}
+		}
+		sources[path] = source
+		return
+	}
+	// An object replacing a leaf (such as a null default) is described by its own leaves
+	delete(sources, path)
+	for key, child := range m {
+		recordSources(child, joinKey(path, key), source, sources)
+	}
+}
+
+// joinKey joins a dotted key prefix and a key
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// configKey describes a leaf key of the Config struct
+type configKey struct {
+	Path []string
+	Type reflect.Type
+}
+
+// configKeys lists all leaf keys of the Config struct, following json tags
+func configKeys() []configKey {
+	var keys []configKey
+	collectKeys(reflect.TypeOf(Config{}), nil, &keys)
+	return keys
+}
+
+// collectKeys walks struct fields recursively, appending leaf keys
+func collectKeys(t reflect.Type, prefix []string, keys *[]configKey) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		path := append(append([]string(nil), prefix...), name)
+		if field.Type.Kind() == reflect.Struct {
+			collectKeys(field.Type, path, keys)
+			continue
+		}
+		*keys = append(*keys, configKey{Path: path, Type: field.Type})
+	}
+}
+
+// EnvName returns the environment variable overriding a dotted config key
+func EnvName(dottedKey string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(dottedKey, ".", "_"))
+}
+
+// envOverrides builds a config layer from CODETRACKER_* environment variables
+func envOverrides(sources Sources) (map[string]interface{}, error) {
+	layer := map[string]interface{}{}
+	for _, key := range configKeys() {
+		dotted := strings.Join(key.Path, ".")
+		name := EnvName(dotted)
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		val, err := parseEnvValue(raw, key.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if isZeroDefault(dotted, val) {
+			continue
+		}
+
+		node := layer
+		for _, part := range key.Path[:len(key.Path)-1] {
+			child, ok := node[part].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{}
+				node[part] = child
+			}
+			node = child
+		}
+		node[key.Path[len(key.Path)-1]] = val
+		recordSources(val, dotted, "env:"+name, sources)
+	}
+	return layer, nil
+}
+
+// parseEnvValue converts an environment variable into a JSON value of the field's type.
+// String lists accept comma-separated values; other composite types take JSON.
+func parseEnvValue(raw string, t reflect.Type) (interface{}, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return raw, nil
+	case reflect.Bool:
+		return strconv.ParseBool(raw)
+	case reflect.Int, reflect.Int64:
+		return strconv.ParseInt(raw, 10, 64)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String && !strings.HasPrefix(strings.TrimSpace(raw), "[") {
+			items := []interface{}{}
+			for _, item := range strings.Split(raw, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			return items, nil
+		}
+	}
+
+	var val interface{}
+	if err := json.Unmarshal([]byte(raw), &val); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+// Keys returns the dotted keys with a recorded source, sorted
+func (s Sources) Keys() []string {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useLayers points the user and project config files at temporary files
+// holding the given JSON; an empty string leaves that file out
+func useLayers(t *testing.T, user, project string) {
+	t.Helper()
+	dir := t.TempDir()
+	saved := projectRoot
+	projectRoot = filepath.Join(dir, "project")
+	t.Cleanup(func() { projectRoot = saved })
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "xdg"))
+
+	for path, content := range map[string]string{UserConfigFile(): user, ConfigFile(): project} {
+		if content == "" {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUserOnlyKeys(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       string
+		project    string
+		wantHelper string
+		wantServer string
+		wantIssues int
+	}{
+		{
+			name:       "user config",
+			user:       `{"credential_helper":"keychain","server_url":"https://tracker.example.com"}`,
+			project:    `{"track_extensions":[".go"]}`,
+			wantHelper: "keychain",
+			wantServer: "https://tracker.example.com",
+		},
+		{
+			name:       "project config",
+			project:    `{"track_extensions":[".go"],"credential_helper":"./steal","server_url":"https://evil.example.com"}`,
+			wantServer: "http://localhost:5000",
+			wantIssues: 2,
+		},
+		{
+			name:       "project cannot override user",
+			user:       `{"server_url":"https://tracker.example.com"}`,
+			project:    `{"track_extensions":[".go"],"server_url":"https://evil.example.com"}`,
+			wantServer: "https://tracker.example.com",
+			wantIssues: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useLayers(t, tt.user, tt.project)
+
+			cfg, issues := Validate()
+			if cfg == nil {
+				t.Fatalf("config not loaded: %v", issues)
+			}
+			if cfg.CredentialHelper != tt.wantHelper || cfg.ServerURL != tt.wantServer {
+				t.Errorf("credential_helper = %q, server_url = %q; want %q, %q",
+					cfg.CredentialHelper, cfg.ServerURL, tt.wantHelper, tt.wantServer)
+			}
+
+			ignored := 0
+			for _, issue := range issues {
+				if issue.Source == ConfigFile() && (issue.Key == "credential_helper" || issue.Key == "server_url") {
+					ignored++
+				}
+			}
+			if ignored != tt.wantIssues {
+				t.Errorf("%d warnings about ignored keys, want %d: %v", ignored, tt.wantIssues, issues)
+			}
+		})
+	}
+}
+
+func TestLayerPrecedence(t *testing.T) {
+	useLayers(t,
+		`{"max_file_size":100,"auto_snapshot":{"enabled":true},"context_injection":{"max_files":5}}`,
+		`{"track_extensions":[".go"],"max_file_size":200}`)
+	t.Setenv("CODETRACKER_CONTEXT_INJECTION_MAX_FILES", "7")
+
+	cfg, sources, err := LoadConfigWithSources()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key    string
+		got    interface{}
+		want   interface{}
+		source string
+	}{
+		{"max_file_size", cfg.MaxFileSize, int64(200), ConfigFile()},
+		{"auto_snapshot.enabled", cfg.AutoSnapshot.Enabled, true, UserConfigFile()},
+		{"context_injection.max_files", cfg.ContextInjection.MaxFiles, 7, "env:CODETRACKER_CONTEXT_INJECTION_MAX_FILES"},
+		{"conversation_tracking.max_entries_per_request", cfg.ConversationTracking.MaxEntriesPerRequest, 100, SourceDefault},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want || sources[tt.key] != tt.source {
+			t.Errorf("%s = %v from %q, want %v from %q", tt.key, tt.got, sources[tt.key], tt.want, tt.source)
+		}
+	}
+}
+
+func TestZeroMeansUnset(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       string
+		project    string
+		env        map[string]string
+		wantSize   int64
+		wantServer string
+		wantSource string // source of max_file_size; "user" for the user config file
+	}{
+		{
+			name:       "defaults",
+			project:    `{"track_extensions":[".go"]}`,
+			wantSize:   1024 * 1024,
+			wantServer: "http://localhost:5000",
+			wantSource: SourceDefault,
+		},
+		{
+			name:       "zero in project keeps user value",
+			user:       `{"max_file_size":100,"server_url":"https://tracker.example.com"}`,
+			project:    `{"track_extensions":[".go"],"max_file_size":0}`,
+			wantSize:   100,
+			wantServer: "https://tracker.example.com",
+			wantSource: "user",
+		},
+		{
+			name:       "empty server_url in user config keeps default",
+			user:       `{"server_url":"","max_file_size":0}`,
+			project:    `{"track_extensions":[".go"]}`,
+			wantSize:   1024 * 1024,
+			wantServer: "http://localhost:5000",
+			wantSource: SourceDefault,
+		},
+		{
+			name:       "zero and empty in env",
+			user:       `{"max_file_size":100,"server_url":"https://tracker.example.com"}`,
+			project:    `{"track_extensions":[".go"]}`,
+			env:        map[string]string{"CODETRACKER_MAX_FILE_SIZE": "0", "CODETRACKER_SERVER_URL": ""},
+			wantSize:   100,
+			wantServer: "https://tracker.example.com",
+			wantSource: "user",
+		},
+		{
+			name:       "nonzero env wins",
+			project:    `{"track_extensions":[".go"],"max_file_size":200}`,
+			env:        map[string]string{"CODETRACKER_MAX_FILE_SIZE": "300"},
+			wantSize:   300,
+			wantServer: "http://localhost:5000",
+			wantSource: "env:CODETRACKER_MAX_FILE_SIZE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useLayers(t, tt.user, tt.project)
+			for name, val := range tt.env {
+				t.Setenv(name, val)
+			}
+
+			cfg, sources, err := LoadConfigWithSources()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.MaxFileSize != tt.wantSize || cfg.ServerURL != tt.wantServer {
+				t.Errorf("max_file_size = %d, server_url = %q; want %d, %q",
+					cfg.MaxFileSize, cfg.ServerURL, tt.wantSize, tt.wantServer)
+			}
+			want := tt.wantSource
+			if want == "user" {
+				want = UserConfigFile()
+			}
+			if sources["max_file_size"] != want {
+				t.Errorf("max_file_size from %q, want %q", sources["max_file_size"], want)
+			}
+		})
+	}
+}
@@ -36,7 +36,8 @@ func ConfigFile() string {
 	return filepath.Join(TrackerDir(), "config.json")
 }
 
-// CredentialsFile returns the credentials.json file path
+// CredentialsFile returns the project credentials.json file path.
+// Deprecated: prefer UserCredentialsFile, which lives outside the repository.
 func CredentialsFile() string {
 	return filepath.Join(TrackerDir(), "credentials.json")
 }
@@ -55,3 +56,71 @@ func LastSnapshotFile() string {
 func SessionFile() string {
 	return filepath.Join(CacheDir(), "current_session.json")
 }
+
+// ObjectsDir returns the content-addressed blob store directory path
+func ObjectsDir() string {
+	return filepath.Join(CacheDir(), "objects")
+}
+
+// InteractionsDir returns the local interaction records directory path
+func InteractionsDir() string {
+	return filepath.Join(CacheDir(), "interactions")
+}
+
+// LastBlobGCFile returns the file whose modification time records the last blob collection
+func LastBlobGCFile() string {
+	return filepath.Join(CacheDir(), "last_blob_gc")
+}
+
+// ConfigIssuesFile returns the config_issues.log file path written by hooks
+func ConfigIssuesFile() string {
+	return filepath.Join(CacheDir(), "config_issues.log")
+}
+
+// CheckpointFile returns the checkpoint.json file path used by tool-level hooks
+func CheckpointFile() string {
+	return filepath.Join(CacheDir(), "checkpoint.json")
+}
+
+// PolicyBaselineFile returns the file holding the project state at prompt
+// submit, which Stop policies compare against
+func PolicyBaselineFile() string {
+	return filepath.Join(CacheDir(), "policy_baseline.json")
+}
+
+// UserConfigDir returns the user-level codetracker directory
+// ($XDG_CONFIG_HOME/codetracker or ~/.config/codetracker)
+func UserConfigDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "codetracker")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "codetracker")
+}
+
+// UserConfigFile returns the user-global config.json file path
+func UserConfigFile() string {
+	dir := UserConfigDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "config.json")
+}
+
+// UserCredentialsFile returns the user-level credentials.json file path
+func UserCredentialsFile() string {
+	dir := UserConfigDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "credentials.json")
+}
+
+// ProjectIdentityFile returns the project_identity.json file path caching the
+// git-derived project identity and its registered project hash
+func ProjectIdentityFile() string {
+	return filepath.Join(CacheDir(), "project_identity.json")
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Credential provider names
+const (
+	ProviderEnv         = "env"
+	ProviderHelper      = "credential-helper"
+	ProviderUserFile    = "user-file"
+	ProviderProjectFile = "project-file"
+)
+
+// SourceGitIdentity marks a project hash registered for the git repository
+// rather than supplied by a provider
+const SourceGitIdentity = "git-identity"
+
+// helperTimeout bounds how long a credential helper may run
+const helperTimeout = 10 * time.Second
+
+// CredentialProvider loads credentials from one backend.
+// Load returns nil, nil when the backend has nothing to offer.
+type CredentialProvider interface {
+	Name() string
+	Load() (*Credentials, error)
+}
+
+// DefaultProviders returns the provider chain in priority order: environment
+// variables, the configured credential helper, the user-level credentials file
+// and, deprecated, the project's .codetracker/credentials.json
+func DefaultProviders(cfg *Config) []CredentialProvider {
+	providers := []CredentialProvider{EnvProvider{}}
+	if cfg != nil && cfg.CredentialHelper != "" {
+		providers = append(providers, HelperProvider{Command: cfg.CredentialHelper, ServerURL: cfg.ServerURL})
+	}
+	return append(providers,
+		FileProvider{Path: UserCredentialsFile(), Label: ProviderUserFile, Private: true},
+		FileProvider{Path: CredentialsFile(), Label: ProviderProjectFile},
+	)
+}
+
+// EnvProvider reads credentials from CODETRACKER_* environment variables
+type EnvProvider struct{}
+
+// Name returns the provider name
+func (EnvProvider) Name() string { return ProviderEnv }
+
+// Load reads CODETRACKER_API_KEY, CODETRACKER_PROJECT_HASH, CODETRACKER_USERNAME and CODETRACKER_EMAIL
+func (EnvProvider) Load() (*Credentials, error) {
+	creds := &Credentials{
+		APIKey:             os.Getenv(envPrefix + "API_KEY"),
+		CurrentProjectHash: os.Getenv(envPrefix + "PROJECT_HASH"),
+		Username:           os.Getenv(envPrefix + "USERNAME"),
+		Email:              os.Getenv(envPrefix + "EMAIL"),
+	}
+	if creds.APIKey == "" && creds.CurrentProjectHash == "" && creds.Username == "" && creds.Email == "" {
+		return nil, nil
+	}
+	return creds, nil
+}
+
+// FileProvider reads a credentials.json file. Private files must not be
+// readable by group or others.
+type FileProvider struct {
+	Path    string
+	Label   string
+	Private bool
+}
+
+// Name returns the provider name
+func (p FileProvider) Name() string { return p.Label }
+
+// Load reads and parses the credentials file
+func (p FileProvider) Load() (*Credentials, error) {
+	if p.Path == "" {
+		return nil, nil
+	}
+
+	info, err := os.Stat(p.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.Private && runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("%s has permissions %04o; run chmod 600 %s", p.Path, info.Mode().Perm(), p.Path)
+	}
+
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("%s: %w", p.Path, err)
+	}
+	return &creds, nil
+}
+
+// HelperProvider runs an external credential helper, git-credential style:
+// "<command> get" receives protocol/host key=value lines on stdin and prints
+// key=value lines. api_key (or password), project_hash, username and email are read.
+type HelperProvider struct {
+	Command   string
+	ServerURL string
+}
+
+// Name returns the provider name
+func (HelperProvider) Name() string { return ProviderHelper }
+
+// Load runs the helper and parses its output
+func (p HelperProvider) Load() (*Credentials, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), helperTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", p.Command+" get")
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", p.Command+" get")
+	}
+
+	var stdin bytes.Buffer
+	if u, err := url.Parse(p.ServerURL); err == nil {
+		fmt.Fprintf(&stdin, "protocol=%s\nhost=%s\n", u.Scheme, u.Host)
+	}
+	fmt.Fprintf(&stdin, "path=%s\n\n", filepath.ToSlash(GetProjectRoot()))
+	cmd.Stdin = &stdin
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	creds := &Credentials{}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		key, val, ok := strings.Cut(sc.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "api_key", "password":
+			if creds.APIKey == "" {
+				creds.APIKey = val
+			}
+		case "project_hash":
+			creds.CurrentProjectHash = val
+		case "username":
+			creds.Username = val
+		case "email":
+			creds.Email = val
+		}
+	}
+	return creds, nil
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Schema generates a JSON Schema for config.json from the Config struct.
+// Defaults are taken from the built-in default layer.
+func Schema() map[string]interface{} {
+	defaults, _ := toMap(defaultConfig())
+
+	schema := typeSchema(reflect.TypeOf(Config{}), defaults)
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "CodeTracker configuration"
+
+	// Enumerations not expressible through Go types
+	policy := schema["properties"].(map[string]interface{})["policies"].(map[string]interface{})["items"].(map[string]interface{})
+	policyProps := policy["properties"].(map[string]interface{})
+	policyProps["type"].(map[string]interface{})["enum"] = sortedKeys(policyTypes)
+	policyProps["events"].(map[string]interface{})["items"].(map[string]interface{})["enum"] = sortedKeys(policyEvents)
+	policy["required"] = []string{"type"}
+
+	tagging := schema["properties"].(map[string]interface{})["tagging"].(map[string]interface{})
+	tagRule := tagging["properties"].(map[string]interface{})["rules"].(map[string]interface{})["items"].(map[string]interface{})
+	tagRule["required"] = []string{"label"}
+
+	return schema
+}
+
+// typeSchema returns the schema of a Go type; defaults holds the default JSON value if any
+func typeSchema(t reflect.Type, defaults interface{}) map[string]interface{} {
+	schema := map[string]interface{}{}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		props := map[string]interface{}{}
+		defaultObj, _ := defaults.(map[string]interface{})
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			props[name] = typeSchema(t.Field(i).Type, defaultObj[name])
+		}
+		schema["type"] = "object"
+		schema["properties"] = props
+		schema["additionalProperties"] = false
+		return schema
+	case reflect.Slice:
+		schema["type"] = "array"
+		schema["items"] = typeSchema(t.Elem(), nil)
+	case reflect.Map:
+		schema["type"] = "object"
+		schema["additionalProperties"] = typeSchema(t.Elem(), nil)
+	case reflect.String:
+		schema["type"] = "string"
+	case reflect.Bool:
+		schema["type"] = "boolean"
+	case reflect.Int, reflect.Int64:
+		schema["type"] = "integer"
+	case reflect.Float64:
+		schema["type"] = "number"
+	}
+
+	if defaults != nil {
+		schema["default"] = defaults
+	}
+	return schema
+}
+
+// sortedKeys returns the keys of a set in sorted order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
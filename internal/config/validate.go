@@ -0,0 +1,335 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Issue severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Issue is a problem found while validating the configuration
+type Issue struct {
+	Severity string `json:"severity"`
+	Key      string `json:"key"`
+	Source   string `json:"source,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (i Issue) String() string {
+	s := fmt.Sprintf("%s: %s: %s", i.Severity, i.Key, i.Message)
+	if i.Source != "" {
+		s += " [" + i.Source + "]"
+	}
+	return s
+}
+
+// policyTypes are the supported PolicyRule types
+var policyTypes = map[string]bool{"path": true, "max_files": true, "secret": true}
+
+// policyEvents are the hook events policies can be evaluated on
+var policyEvents = map[string]bool{"UserPromptSubmit": true, "Stop": true}
+
+// Validate checks every config layer for unknown keys and type errors, then
+// checks the effective configuration for values that would silently disable
+// or break tracking. The effective config is nil if it cannot be loaded.
+// Environment variable errors are reported under the "(config)" key.
+func Validate() (*Config, []Issue) {
+	var issues []Issue
+
+	for _, path := range []string{UserConfigFile(), ConfigFile()} {
+		issues = append(issues, validateLayer(path)...)
+	}
+
+	cfg, sources, err := resolve()
+	if cfg == nil {
+		issues = append(issues, Issue{Severity: SeverityError, Key: "(config)", Message: err.Error()})
+		return nil, issues
+	}
+
+	// Type errors were reported per layer; check what could be decoded
+	issues = append(issues, Check(cfg, sources)...)
+	if err != nil {
+		return nil, issues
+	}
+	return cfg, issues
+}
+
+// validateLayer reports parse errors, unknown keys and type mismatches in one config file
+func validateLayer(path string) []Issue {
+	layer, err := readLayer(path)
+	if err != nil {
+		return []Issue{{Severity: SeverityError, Key: "(file)", Source: path, Message: err.Error()}}
+	}
+	if layer == nil {
+		return nil
+	}
+
+	var issues []Issue
+	for _, key := range unknownKeys(layer, reflect.TypeOf(Config{}), "") {
+		issues = append(issues, Issue{Severity: SeverityWarning, Key: key.path, Source: path, Message: key.message()})
+	}
+	if path == ConfigFile() {
+		for _, key := range userOnlyKeys {
+			if _, ok := layer[key]; ok {
+				issues = append(issues, Issue{Severity: SeverityWarning, Key: key, Source: path,
+					Message: "ignored in the project config; set it in the user config or " + EnvName(key)})
+			}
+		}
+	}
+
+	// Decoding into Config catches values of the wrong type; unknown keys were reported above
+	data, _ := json.Marshal(layer)
+	var cfg Config
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&cfg); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		key := "(file)"
+		if errors.As(err, &typeErr) {
+			key = typeErr.Field
+		}
+		issues = append(issues, Issue{Severity: SeverityError, Key: key, Source: path, Message: err.Error()})
+	}
+
+	return issues
+}
+
+// unknownKey is a key not present in the Config struct
+type unknownKey struct {
+	path       string
+	suggestion string
+}
+
+func (k unknownKey) message() string {
+	if k.suggestion != "" {
+		return fmt.Sprintf("unknown key (did you mean %q?)", k.suggestion)
+	}
+	return "unknown key"
+}
+
+// unknownKeys walks a raw JSON value against a struct type and returns keys the struct does not define
+func unknownKeys(raw interface{}, t reflect.Type, prefix string) []unknownKey {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return unknownKeys(raw, t.Elem(), prefix)
+	case reflect.Slice:
+		arr, ok := raw.([]interface{})
+		if !ok {
+			return nil
+		}
+		var keys []unknownKey
+		for i, item := range arr {
+			keys = append(keys, unknownKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", prefix, i))...)
+		}
+		return keys
+	case reflect.Map:
+		obj, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		var keys []unknownKey
+		for name, item := range obj {
+			keys = append(keys, unknownKeys(item, t.Elem(), joinKey(prefix, name))...)
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i].path < keys[j].path })
+		return keys
+	case reflect.Struct:
+	default:
+		return nil
+	}
+
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = t.Field(i).Type
+		}
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var keys []unknownKey
+	for _, name := range names {
+		path := joinKey(prefix, name)
+		fieldType, ok := fields[name]
+		if !ok {
+			keys = append(keys, unknownKey{path: path, suggestion: suggest(name, fields)})
+			continue
+		}
+		keys = append(keys, unknownKeys(obj[name], fieldType, path)...)
+	}
+	return keys
+}
+
+// suggest returns the closest known field name to name, if it is close enough
+func suggest(name string, fields map[string]reflect.Type) string {
+	best, bestDist := "", 3
+	for field := range fields {
+		if d := editDistance(name, field); d < bestDist || (d == bestDist && field < best) {
+			best, bestDist = field, d
+		}
+	}
+	return best
+}
+
+// editDistance computes the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// Check performs semantic checks on an effective configuration
+func Check(cfg *Config, sources Sources) []Issue {
+	var issues []Issue
+	add := func(severity, key, format string, args ...interface{}) {
+		source := sources[key]
+		if i := strings.Index(key, "["); i >= 0 {
+			source = sources[key[:i]]
+		}
+		issues = append(issues, Issue{Severity: severity, Key: key, Source: source, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if u, err := url.Parse(cfg.ServerURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add(SeverityError, "server_url", "%q is not a valid http(s) URL", cfg.ServerURL)
+	}
+
+	if len(cfg.TrackExtensions) == 0 {
+		if _, err := os.Stat(ConfigFile()); errors.Is(err, os.ErrNotExist) {
+			// A project without its own config is not set up for tracking yet
+			issues = append(issues, Issue{Severity: SeverityWarning, Key: "track_extensions", Source: ConfigFile(),
+				Message: "no project config; no files will be tracked"})
+		} else {
+			add(SeverityError, "track_extensions", "empty; no files will be tracked")
+		}
+	}
+	for i, ext := range cfg.TrackExtensions {
+		if !strings.HasPrefix(ext, ".") {
+			add(SeverityWarning, fmt.Sprintf("track_extensions[%d]", i), "%q does not start with '.' and will never match", ext)
+		}
+	}
+
+	if cfg.MaxFileSize < 0 {
+		add(SeverityError, "max_file_size", "must not be negative (0 uses the default); every file will be skipped")
+	}
+
+	for i, pattern := range cfg.AutoSnapshot.SkipPatterns {
+		if _, err := regexp.Compile("(?i)" + pattern); err != nil {
+			add(SeverityError, fmt.Sprintf("auto_snapshot.skip_patterns[%d]", i), "invalid regular expression: %v", err)
+		}
+	}
+
+	if cfg.ConversationTracking.MaxEntriesPerRequest < 0 {
+		add(SeverityError, "conversation_tracking.max_entries_per_request", "must not be negative (0 uses the default)")
+	}
+	if cfg.ConversationTracking.IncludeThinking && !cfg.ConversationTracking.IncludeAssistantMetadata {
+		add(SeverityWarning, "conversation_tracking.include_thinking", "has no effect unless include_assistant_metadata is enabled")
+	}
+
+	if cfg.History.MaxInteractions < 0 {
+		add(SeverityError, "history.max_interactions", "must not be negative (0 keeps every interaction)")
+	} else if cfg.History.MaxInteractions == 0 && cfg.History.MaxAgeDays == 0 {
+		add(SeverityWarning, "history", "no retention limit; the local interaction history and blob store grow without bound")
+	}
+	if cfg.History.MaxAgeDays < 0 {
+		add(SeverityError, "history.max_age_days", "must not be negative (0 keeps interactions of any age)")
+	}
+
+	models := make([]string, 0, len(cfg.Pricing))
+	for model := range cfg.Pricing {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	for _, model := range models {
+		price := cfg.Pricing[model]
+		if model == "" {
+			add(SeverityError, "pricing", "model key must not be empty")
+		}
+		if price.Input < 0 || price.Output < 0 || price.CacheWrite < 0 || price.CacheRead < 0 {
+			add(SeverityError, "pricing."+model, "prices must not be negative")
+		}
+	}
+
+	for i, rule := range cfg.Policies {
+		key := fmt.Sprintf("policies[%d]", i)
+		if !policyTypes[rule.Type] {
+			add(SeverityError, key+".type", "unknown policy type %q (expected path, max_files or secret)", rule.Type)
+		}
+		if rule.Type == "path" && len(rule.Paths) == 0 {
+			add(SeverityError, key+".paths", "path policy has no paths and never matches")
+		}
+		if rule.Type == "max_files" && rule.MaxFiles <= 0 {
+			add(SeverityError, key+".max_files", "max_files policy needs a positive max_files")
+		}
+		for j, pattern := range rule.Patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				add(SeverityError, fmt.Sprintf("%s.patterns[%d]", key, j), "invalid regular expression: %v", err)
+			}
+		}
+		for j, event := range rule.Events {
+			if !policyEvents[event] {
+				add(SeverityWarning, fmt.Sprintf("%s.events[%d]", key, j), "unknown event %q (expected UserPromptSubmit or Stop)", event)
+			} else if event == "UserPromptSubmit" && (rule.Type == "path" || rule.Type == "max_files") {
+				add(SeverityWarning, fmt.Sprintf("%s.events[%d]", key, j), "%s policy only checks changes and is not evaluated on UserPromptSubmit", rule.Type)
+			}
+		}
+	}
+
+	for i, rule := range cfg.Tagging.Rules {
+		key := fmt.Sprintf("tagging.rules[%d]", i)
+		if rule.Label == "" {
+			add(SeverityError, key+".label", "tag rule has no label")
+		}
+		if len(rule.Patterns) == 0 && len(rule.Keywords) == 0 && len(rule.Commands) == 0 {
+			add(SeverityWarning, key, "tag rule has no patterns, keywords or commands and never matches")
+		}
+	}
+	for _, invalid := range cfg.Tagging.invalid {
+		add(SeverityError, invalid.key, "invalid regular expression: %v", invalid.err)
+	}
+
+	return issues
+}
+
+// HasErrors reports whether any issue is an error
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -13,6 +13,23 @@ const (
 	Deleted  ChangeType = "D"
 )
 
+// Change sources other than tool names
+const (
+	// SourceUnattributed marks a change with no matching tool call
+	SourceUnattributed = "unattributed"
+	// SourceHuman marks a change made outside any tool during a prompt,
+	// found by the pre_tool_use hook before a tool ran
+	SourceHuman = "human"
+	// SourceSubagent marks a change made by a subagent
+	SourceSubagent = "subagent"
+)
+
+// Attribution is the source recorded for a file changed during a prompt
+type Attribution struct {
+	Source     string   `json:"source"`
+	ToolUseIDs []string `json:"tool_use_ids,omitempty"`
+}
+
 // Change represents a file change
 type Change struct {
 	FilePath     string     `json:"file_path"`
@@ -21,6 +38,8 @@ type Change struct {
 	Content      string     `json:"content,omitempty"`
 	Size         int64      `json:"size,omitempty"`
 	PreviousHash string     `json:"previous_hash,omitempty"`
+	Source       string     `json:"source,omitempty"`
+	ToolUseIDs   []string   `json:"tool_use_ids,omitempty"`
 }
 
 // SnapshotFileInfo holds cached file info from previous snapshot
@@ -0,0 +1,34 @@
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Identity identifies a project independently of where it is checked out
+type Identity struct {
+	ID         string `json:"id"`
+	RemoteURL  string `json:"remote_url,omitempty"`
+	RootCommit string `json:"root_commit"`
+}
+
+// ProjectIdentity derives a stable identity from the normalized origin URL
+// and the root commit, so clones share it and unrelated repos never do
+func (r *Repo) ProjectIdentity() (*Identity, error) {
+	root, err := r.RootCommit()
+	if err != nil {
+		return nil, err
+	}
+	return NewIdentity(r.RemoteURL("origin"), root), nil
+}
+
+// NewIdentity computes the identity of a repository from its remote URL and root commit
+func NewIdentity(remoteURL, rootCommit string) *Identity {
+	remote := NormalizeURL(remoteURL)
+	sum := sha256.Sum256([]byte(remote + "\n" + rootCommit))
+	return &Identity{
+		ID:         hex.EncodeToString(sum[:]),
+		RemoteURL:  remote,
+		RootCommit: rootCommit,
+	}
+}
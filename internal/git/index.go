@@ -0,0 +1,211 @@
+package git
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Index entry flags
+const (
+	indexFlagExtended     = 0x4000
+	indexFlagStageMask    = 0x3000
+	indexFlagSkipWorktree = 0x4000 // in the extended flags
+)
+
+// IndexEntry is one file in the git index (staging area)
+type IndexEntry struct {
+	Path         string
+	SHA          string
+	Mode         uint32
+	Size         uint32
+	MtimeSec     uint32
+	MtimeNsec    uint32
+	Stage        int
+	SkipWorktree bool
+}
+
+// IndexFile returns the path of the index git is using. Hooks run by
+// "git commit -a" or "git commit <paths>" get a temporary index through
+// GIT_INDEX_FILE, which is relative to the work tree when not absolute.
+func (r *Repo) IndexFile() string {
+	if path := os.Getenv("GIT_INDEX_FILE"); path != "" {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(r.WorkTree, path)
+		}
+		return path
+	}
+	return filepath.Join(r.GitDir, "index")
+}
+
+// ReadIndex parses the index file (versions 2, 3 and 4)
+func (r *Repo) ReadIndex() ([]*IndexEntry, error) {
+	entries, _, err := r.readIndex()
+	return entries, err
+}
+
+// readIndex parses the index file and returns its entries and the tree its
+// cache-tree extension records for them, if valid
+func (r *Repo) readIndex() ([]*IndexEntry, string, error) {
+	data, err := os.ReadFile(r.IndexFile())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return parseIndex(data)
+}
+
+// parseIndex decodes the entries of an index file and the root tree of its
+// cache-tree extension ("" when absent or invalidated); other extensions are ignored
+func parseIndex(data []byte) ([]*IndexEntry, string, error) {
+	errTruncated := errors.New("truncated index")
+
+	if len(data) < 12 || string(data[:4]) != "DIRC" {
+		return nil, "", errors.New("not an index file")
+	}
+	version := binary.BigEndian.Uint32(data[4:8])
+	if version < 2 || version > 4 {
+		return nil, "", fmt.Errorf("unsupported index version %d", version)
+	}
+	count := int(binary.BigEndian.Uint32(data[8:12]))
+
+	entries := make([]*IndexEntry, 0, count)
+	pos := 12
+	prevPath := ""
+	for i := 0; i < count; i++ {
+		start := pos
+		// ctime, mtime, dev, ino, mode, uid, gid, size, sha, flags
+		if len(data) < pos+62 {
+			return nil, "", errTruncated
+		}
+		entry := &IndexEntry{
+			MtimeSec:  binary.BigEndian.Uint32(data[pos+8:]),
+			MtimeNsec: binary.BigEndian.Uint32(data[pos+12:]),
+			Mode:      binary.BigEndian.Uint32(data[pos+24:]),
+			Size:      binary.BigEndian.Uint32(data[pos+36:]),
+			SHA:       hex.EncodeToString(data[pos+40 : pos+60]),
+		}
+		flags := binary.BigEndian.Uint16(data[pos+60:])
+		entry.Stage = int(flags&indexFlagStageMask) >> 12
+		pos += 62
+
+		if flags&indexFlagExtended != 0 {
+			if len(data) < pos+2 {
+				return nil, "", errTruncated
+			}
+			entry.SkipWorktree = binary.BigEndian.Uint16(data[pos:])&indexFlagSkipWorktree != 0
+			pos += 2
+		}
+
+		if version == 4 {
+			// Path is stored as: bytes to strip from the previous path, then a NUL-terminated suffix
+			strip, n := readOffsetVarint(data[pos:])
+			if n == 0 || strip > len(prevPath) {
+				return nil, "", errTruncated
+			}
+			pos += n
+			end := bytes.IndexByte(data[pos:], 0)
+			if end < 0 {
+				return nil, "", errTruncated
+			}
+			entry.Path = prevPath[:len(prevPath)-strip] + string(data[pos:pos+end])
+			pos += end + 1
+		} else {
+			// NUL-terminated path, padded so the entry length is a multiple of 8
+			end := bytes.IndexByte(data[pos:], 0)
+			if end < 0 {
+				return nil, "", errTruncated
+			}
+			entry.Path = string(data[pos : pos+end])
+			pos = start + (pos-start+end+8)&^7
+		}
+
+		prevPath = entry.Path
+		entries = append(entries, entry)
+	}
+
+	// Extensions follow the entries, before the trailing checksum
+	rootTree := ""
+	for pos+8 <= len(data)-sha1.Size {
+		signature := string(data[pos : pos+4])
+		size := int(binary.BigEndian.Uint32(data[pos+4:]))
+		pos += 8
+		if size > len(data)-sha1.Size-pos {
+			break
+		}
+		if signature == "TREE" {
+			rootTree = cacheTreeRoot(data[pos : pos+size])
+		}
+		pos += size
+	}
+
+	return entries, rootTree, nil
+}
+
+// cacheTreeRoot returns the tree SHA the cache-tree extension records for the
+// whole index, or "" when the index changed since it was computed. Entries
+// are a NUL-terminated path (empty for the root), "<entries> <subtrees>\n" and,
+// unless entries is -1, the tree SHA.
+func cacheTreeRoot(ext []byte) string {
+	if len(ext) == 0 || ext[0] != 0 {
+		return ""
+	}
+	eol := bytes.IndexByte(ext, '\n')
+	if eol < 0 {
+		return ""
+	}
+	counts := strings.Fields(string(ext[1:eol]))
+	if len(counts) != 2 {
+		return ""
+	}
+	n, err := strconv.Atoi(counts[0])
+	if err != nil || n < 0 || len(ext) < eol+1+sha1.Size {
+		return ""
+	}
+	return hex.EncodeToString(ext[eol+1 : eol+1+sha1.Size])
+}
+
+// readOffsetVarint decodes git's offset varint (as used by index v4 and OFS_DELTA).
+// It returns the value and the number of bytes read, or 0 bytes on truncation.
+func readOffsetVarint(b []byte) (int, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	c := b[0]
+	value := int(c & 0x7f)
+	n := 1
+	for c&0x80 != 0 {
+		if n >= len(b) {
+			return 0, 0
+		}
+		c = b[n]
+		n++
+		value = ((value + 1) << 7) | int(c&0x7f)
+	}
+	return value, n
+}
+
+// IndexFiles returns the staged files (stage 0 entries) keyed by path, for comparison with HeadFiles
+func (r *Repo) IndexFiles() (map[string]TreeEntry, error) {
+	entries, err := r.ReadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]TreeEntry, len(entries))
+	for _, entry := range entries {
+		if entry.Stage == 0 {
+			files[entry.Path] = TreeEntry{SHA: entry.SHA, Mode: entry.Mode}
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,109 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReadIndexVersions(t *testing.T) {
+	tests := []struct {
+		name string
+		// change modifies the committed work tree and index
+		change    func(t *testing.T, dir string)
+		dirty     bool
+		cacheTree bool
+	}{
+		{
+			name:      "clean",
+			change:    func(t *testing.T, dir string) {},
+			cacheTree: true,
+		},
+		{
+			name: "unstaged edit",
+			change: func(t *testing.T, dir string) {
+				writeTestFile(t, dir, "src/pkg/b.go", "package pkg // edited\n")
+			},
+			dirty:     true,
+			cacheTree: true,
+		},
+		{
+			name: "staged edit",
+			change: func(t *testing.T, dir string) {
+				writeTestFile(t, dir, "docs/guide.md", "# edited guide\n")
+				runGit(t, dir, "add", "docs/guide.md")
+			},
+			dirty: true,
+		},
+		{
+			name: "staged deletion",
+			change: func(t *testing.T, dir string) {
+				runGit(t, dir, "rm", "-q", "src/pkg/a.go")
+			},
+			dirty: true,
+		},
+		{
+			// Intent-to-add entries need the extended flags of version 3
+			name: "intent to add",
+			change: func(t *testing.T, dir string) {
+				writeTestFile(t, dir, "src/pkg/new.go", "package pkg\n")
+				runGit(t, dir, "add", "-N", "src/pkg/new.go")
+			},
+			dirty: true,
+		},
+		{
+			name: "skip worktree",
+			change: func(t *testing.T, dir string) {
+				runGit(t, dir, "update-index", "--skip-worktree", "src/pkg/b.go")
+				writeTestFile(t, dir, "src/pkg/b.go", "package pkg // ignored\n")
+			},
+		},
+	}
+
+	for _, version := range []int{2, 3, 4} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("v%d %s", version, tt.name), func(t *testing.T) {
+				dir := newTestRepo(t)
+				// Paths sharing long prefixes exercise the prefix compression of version 4
+				for _, path := range []string{"src/pkg/a.go", "src/pkg/b.go", "src/pkg/sub/c.go", "docs/guide.md"} {
+					writeTestFile(t, dir, path, "package pkg\n// "+path+"\n")
+				}
+				runGit(t, dir, "add", ".")
+				runGit(t, dir, "commit", "-q", "-m", "files")
+
+				tt.change(t, dir)
+				runGit(t, dir, "update-index", "--index-version", fmt.Sprint(version))
+
+				repo, err := Open(dir)
+				if err != nil {
+					t.Fatal(err)
+				}
+				entries, rootTree, err := repo.readIndex()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// git ls-files -s prints "<mode> <sha> <stage>\t<path>"
+				var got []string
+				for _, e := range entries {
+					got = append(got, fmt.Sprintf("%o %s %d\t%s", e.Mode, e.SHA, e.Stage, e.Path))
+				}
+				if want := runGit(t, dir, "ls-files", "-s"); strings.Join(got, "\n") != want {
+					t.Errorf("entries:\n%s\nwant:\n%s", strings.Join(got, "\n"), want)
+				}
+
+				if tt.cacheTree && rootTree != runGit(t, dir, "rev-parse", "HEAD^{tree}") {
+					t.Errorf("cache-tree root = %q, want HEAD's tree", rootTree)
+				}
+
+				dirty, err := repo.IsDirty()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if dirty != tt.dirty {
+					t.Errorf("IsDirty() = %v, want %v", dirty, tt.dirty)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package git
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Metadata is the VCS context attached to snapshots and interactions
+type Metadata struct {
+	Branch   string `json:"branch,omitempty"`
+	HeadSHA  string `json:"head_sha,omitempty"`
+	Dirty    bool   `json:"dirty"`
+	Upstream string `json:"upstream,omitempty"`
+}
+
+// Metadata collects the current branch (empty when detached), HEAD commit,
+// dirty state and upstream branch
+func (r *Repo) Metadata() (*Metadata, error) {
+	ref, err := r.HeadRef()
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &Metadata{Branch: strings.TrimPrefix(ref, "refs/heads/")}
+
+	meta.HeadSHA, err = r.Head()
+	if err != nil && !errors.Is(err, ErrNoCommits) {
+		return nil, err
+	}
+
+	if meta.Branch != "" {
+		meta.Upstream = r.upstream(meta.Branch)
+	}
+
+	meta.Dirty, err = r.IsDirty()
+	if err != nil {
+		return nil, err
+	}
+
+	return meta, nil
+}
+
+// upstream returns the configured upstream of a branch as "remote/branch",
+// or just the branch for local upstreams
+func (r *Repo) upstream(branch string) string {
+	cfg, err := r.ReadConfig()
+	if err != nil {
+		return ""
+	}
+	remote := cfg.Get("branch." + branch + ".remote")
+	merge := strings.TrimPrefix(cfg.Get("branch."+branch+".merge"), "refs/heads/")
+	if remote == "" || merge == "" {
+		return ""
+	}
+	if remote == "." {
+		return merge
+	}
+	return remote + "/" + merge
+}
+
+// IsDirty reports whether tracked files differ between HEAD, the index and the
+// working tree. Untracked files are not considered.
+func (r *Repo) IsDirty() (bool, error) {
+	entries, rootTree, err := r.readIndex()
+	if err != nil {
+		return false, err
+	}
+
+	head, err := r.Head()
+	if errors.Is(err, ErrNoCommits) {
+		return len(entries) > 0, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	staged, err := r.hasStagedChanges(head, entries, rootTree)
+	if err != nil || staged {
+		return staged, err
+	}
+
+	// Unstaged changes
+	for _, entry := range entries {
+		if entry.SkipWorktree || entry.Mode == ModeGitlink {
+			continue
+		}
+		modified, err := r.worktreeModified(entry)
+		if err != nil {
+			return false, err
+		}
+		if modified {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// hasStagedChanges reports whether the index differs from the given commit.
+// A valid cache-tree answers by comparing one tree SHA; otherwise every entry
+// is compared with the commit's flattened tree.
+func (r *Repo) hasStagedChanges(commitSHA string, entries []*IndexEntry, rootTree string) (bool, error) {
+	commit, err := r.ReadCommit(commitSHA)
+	if err != nil {
+		return false, err
+	}
+	if rootTree != "" {
+		return rootTree != commit.Tree, nil
+	}
+
+	committed, err := r.ReadTreeFiles(commit.Tree)
+	if err != nil {
+		return false, err
+	}
+	matched := 0
+	for _, entry := range entries {
+		if entry.Stage != 0 {
+			// Unresolved merge conflict
+			return true, nil
+		}
+		file, ok := committed[entry.Path]
+		if !ok || file.SHA != entry.SHA {
+			return true, nil
+		}
+		matched++
+	}
+
+	// Files in the commit but not in the index were deleted and staged
+	return matched != len(committed), nil
+}
+
+// worktreeModified compares a working tree file with its index entry, hashing
+// the content only when size or mtime differ
+func (r *Repo) worktreeModified(entry *IndexEntry) (bool, error) {
+	path := filepath.Join(r.WorkTree, filepath.FromSlash(entry.Path))
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if uint32(info.Size()) != entry.Size {
+		return true, nil
+	}
+	mtime := info.ModTime()
+	if uint32(mtime.Unix()) == entry.MtimeSec && uint32(mtime.Nanosecond()) == entry.MtimeNsec {
+		return false, nil
+	}
+
+	var content []byte
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return false, err
+		}
+		content = []byte(target)
+	} else {
+		content, err = os.ReadFile(path)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return BlobSHA(content) != entry.SHA, nil
+}
+
+// BlobSHA returns the git object ID of a blob with the given content
+func BlobSHA(content []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s %d\x00", TypeBlob, len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,103 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// ErrObjectNotFound is returned when an object is neither loose nor in a pack
+var ErrObjectNotFound = errors.New("object not found")
+
+// Object types
+const (
+	TypeCommit = "commit"
+	TypeTree   = "tree"
+	TypeBlob   = "blob"
+	TypeTag    = "tag"
+)
+
+// objectsDir returns the object database directory
+func (r *Repo) objectsDir() string {
+	return filepath.Join(r.commonDir(), "objects")
+}
+
+// ReadObject returns the type and content of the object with the given hex SHA-1,
+// from the loose object store or a packfile
+func (r *Repo) ReadObject(sha string) (string, []byte, error) {
+	if len(sha) != 40 {
+		return "", nil, fmt.Errorf("invalid object id %q", sha)
+	}
+
+	objType, data, err := r.readLoose(sha)
+	if err == nil {
+		return objType, data, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", nil, err
+	}
+
+	return r.readPacked(sha)
+}
+
+// readLoose reads a zlib-compressed "<type> <size>\0<content>" object file
+func (r *Repo) readLoose(sha string) (string, []byte, error) {
+	file, err := os.Open(filepath.Join(r.objectsDir(), sha[:2], sha[2:]))
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	zr, err := zlib.NewReader(file)
+	if err != nil {
+		return "", nil, err
+	}
+	defer zr.Close()
+
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return "", nil, err
+	}
+
+	header, content, ok := bytes.Cut(raw, []byte{0})
+	if !ok {
+		return "", nil, fmt.Errorf("object %s: missing header", sha)
+	}
+	objType, sizeStr, ok := bytes.Cut(header, []byte{' '})
+	if !ok {
+		return "", nil, fmt.Errorf("object %s: malformed header", sha)
+	}
+	if size, err := strconv.Atoi(string(sizeStr)); err != nil || size != len(content) {
+		return "", nil, fmt.Errorf("object %s: size mismatch", sha)
+	}
+
+	return string(objType), content, nil
+}
+
+// readPacked looks the object up in every pack index
+func (r *Repo) readPacked(sha string) (string, []byte, error) {
+	packs, err := r.loadPacks()
+	if err != nil {
+		return "", nil, err
+	}
+
+	id, err := parseSHA(sha)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, p := range packs {
+		offset, ok := p.find(id)
+		if !ok {
+			continue
+		}
+		return p.readAt(r, offset)
+	}
+
+	return "", nil, ErrObjectNotFound
+}
@@ -0,0 +1,316 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Packed object types
+const (
+	packCommit   = 1
+	packTree     = 2
+	packBlob     = 3
+	packTag      = 4
+	packOfsDelta = 6
+	packRefDelta = 7
+)
+
+// maxDeltaDepth bounds delta chains so a corrupt pack cannot recurse forever
+const maxDeltaDepth = 100
+
+var packTypeNames = map[int]string{
+	packCommit: TypeCommit,
+	packTree:   TypeTree,
+	packBlob:   TypeBlob,
+	packTag:    TypeTag,
+}
+
+// pack is a packfile together with its version 2 index
+type pack struct {
+	path    string
+	ids     [][20]byte
+	offsets []int64
+}
+
+// loadPacks reads the index of every packfile once per Repo
+func (r *Repo) loadPacks() ([]*pack, error) {
+	if r.packs != nil {
+		return r.packs, nil
+	}
+
+	idxFiles, err := filepath.Glob(filepath.Join(r.objectsDir(), "pack", "*.idx"))
+	if err != nil {
+		return nil, err
+	}
+
+	packs := make([]*pack, 0, len(idxFiles))
+	for _, idx := range idxFiles {
+		p, err := readPackIndex(idx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(idx), err)
+		}
+		packs = append(packs, p)
+	}
+
+	r.packs = packs
+	return packs, nil
+}
+
+// readPackIndex parses a version 2 pack index
+func readPackIndex(path string) (*pack, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	const headerLen = 8 + 256*4
+	if len(data) < headerLen || !bytes.Equal(data[:4], []byte{0xff, 't', 'O', 'c'}) || binary.BigEndian.Uint32(data[4:8]) != 2 {
+		return nil, errors.New("unsupported pack index version")
+	}
+
+	count := int(binary.BigEndian.Uint32(data[headerLen-4 : headerLen]))
+	idsStart := headerLen
+	offsetsStart := idsStart + count*20 + count*4 // skip CRC32 table
+	largeStart := offsetsStart + count*4
+	if len(data) < largeStart {
+		return nil, errors.New("truncated pack index")
+	}
+
+	p := &pack{
+		path:    strings.TrimSuffix(path, ".idx") + ".pack",
+		ids:     make([][20]byte, count),
+		offsets: make([]int64, count),
+	}
+	for i := 0; i < count; i++ {
+		copy(p.ids[i][:], data[idsStart+i*20:])
+
+		offset := binary.BigEndian.Uint32(data[offsetsStart+i*4:])
+		if offset&0x80000000 == 0 {
+			p.offsets[i] = int64(offset)
+			continue
+		}
+		// Offsets beyond 2GB live in the 64-bit table
+		large := largeStart + int(offset&0x7fffffff)*8
+		if len(data) < large+8 {
+			return nil, errors.New("truncated pack index")
+		}
+		p.offsets[i] = int64(binary.BigEndian.Uint64(data[large:]))
+	}
+
+	return p, nil
+}
+
+// find returns the pack offset of an object ID
+func (p *pack) find(id [20]byte) (int64, bool) {
+	i := sort.Search(len(p.ids), func(i int) bool {
+		return bytes.Compare(p.ids[i][:], id[:]) >= 0
+	})
+	if i < len(p.ids) && p.ids[i] == id {
+		return p.offsets[i], true
+	}
+	return 0, false
+}
+
+// readAt reads and, for deltas, reconstructs the object at offset
+func (p *pack) readAt(r *Repo, offset int64) (string, []byte, error) {
+	file, err := os.Open(p.path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	return p.readEntry(r, file, offset, 0)
+}
+
+// readEntry decodes one pack entry, resolving delta bases recursively
+func (p *pack) readEntry(r *Repo, file *os.File, offset int64, depth int) (string, []byte, error) {
+	if depth > maxDeltaDepth {
+		return "", nil, errors.New("delta chain too deep")
+	}
+
+	br := bufio.NewReader(io.NewSectionReader(file, offset, 1<<62))
+
+	// Header: type in bits 4-6 of the first byte, size in little-endian 7-bit groups
+	c, err := br.ReadByte()
+	if err != nil {
+		return "", nil, err
+	}
+	objType := int(c>>4) & 7
+	size := int64(c & 0x0f)
+	for shift := 4; c&0x80 != 0; shift += 7 {
+		if c, err = br.ReadByte(); err != nil {
+			return "", nil, err
+		}
+		size |= int64(c&0x7f) << shift
+	}
+
+	var baseType string
+	var base []byte
+	switch objType {
+	case packOfsDelta:
+		// Base offset: big-endian 7-bit groups, each continuation adding one
+		c, err := br.ReadByte()
+		if err != nil {
+			return "", nil, err
+		}
+		rel := int64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = br.ReadByte(); err != nil {
+				return "", nil, err
+			}
+			rel = ((rel + 1) << 7) | int64(c&0x7f)
+		}
+		baseType, base, err = p.readEntry(r, file, offset-rel, depth+1)
+		if err != nil {
+			return "", nil, err
+		}
+	case packRefDelta:
+		var baseID [20]byte
+		if _, err := io.ReadFull(br, baseID[:]); err != nil {
+			return "", nil, err
+		}
+		if baseOffset, ok := p.find(baseID); ok {
+			baseType, base, err = p.readEntry(r, file, baseOffset, depth+1)
+		} else {
+			baseType, base, err = r.ReadObject(hex.EncodeToString(baseID[:]))
+		}
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	data, err := inflate(br, size)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if base == nil {
+		name, ok := packTypeNames[objType]
+		if !ok {
+			return "", nil, fmt.Errorf("unknown pack object type %d", objType)
+		}
+		return name, data, nil
+	}
+
+	result, err := applyDelta(base, data)
+	if err != nil {
+		return "", nil, err
+	}
+	return baseType, result, nil
+}
+
+// inflate decompresses a zlib stream of known size
+func inflate(r io.Reader, size int64) ([]byte, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(zr, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// applyDelta rebuilds an object from its base and a git delta:
+// two size varints followed by copy and insert instructions
+func applyDelta(base, delta []byte) ([]byte, error) {
+	errCorrupt := errors.New("corrupt delta")
+
+	readSize := func() (int, bool) {
+		size, shift := 0, 0
+		for len(delta) > 0 {
+			c := delta[0]
+			delta = delta[1:]
+			size |= int(c&0x7f) << shift
+			shift += 7
+			if c&0x80 == 0 {
+				return size, true
+			}
+		}
+		return 0, false
+	}
+
+	srcSize, ok := readSize()
+	if !ok || srcSize != len(base) {
+		return nil, errCorrupt
+	}
+	dstSize, ok := readSize()
+	if !ok {
+		return nil, errCorrupt
+	}
+
+	out := make([]byte, 0, dstSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		switch {
+		case op&0x80 != 0:
+			// Copy from base: bits 0-3 select offset bytes, bits 4-6 size bytes
+			var offset, size int
+			for i := 0; i < 4; i++ {
+				if op&(1<<i) != 0 {
+					if len(delta) == 0 {
+						return nil, errCorrupt
+					}
+					offset |= int(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+			for i := 0; i < 3; i++ {
+				if op&(0x10<<i) != 0 {
+					if len(delta) == 0 {
+						return nil, errCorrupt
+					}
+					size |= int(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if offset+size > len(base) {
+				return nil, errCorrupt
+			}
+			out = append(out, base[offset:offset+size]...)
+		case op != 0:
+			// Insert the next op bytes literally
+			if int(op) > len(delta) {
+				return nil, errCorrupt
+			}
+			out = append(out, delta[:op]...)
+			delta = delta[op:]
+		default:
+			return nil, errCorrupt
+		}
+	}
+
+	if len(out) != dstSize {
+		return nil, errCorrupt
+	}
+	return out, nil
+}
+
+// parseSHA decodes a hex object ID
+func parseSHA(sha string) ([20]byte, error) {
+	var id [20]byte
+	b, err := hex.DecodeString(sha)
+	if err != nil || len(b) != 20 {
+		return id, fmt.Errorf("invalid object id %q", sha)
+	}
+	copy(id[:], b)
+	return id, nil
+}
@@ -0,0 +1,155 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxSymrefDepth bounds chains of symbolic refs
+const maxSymrefDepth = 5
+
+// ErrNoCommits is returned when HEAD points to a branch with no commits yet
+var ErrNoCommits = errors.New("no commits yet")
+
+// HeadRef returns the ref HEAD points to (e.g. "refs/heads/main"),
+// or "" when HEAD is detached
+func (r *Repo) HeadRef() (string, error) {
+	data, err := os.ReadFile(filepath.Join(r.GitDir, "HEAD"))
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(string(data))
+	if ref, ok := strings.CutPrefix(line, "ref:"); ok {
+		return strings.TrimSpace(ref), nil
+	}
+	return "", nil
+}
+
+// Head returns the commit SHA HEAD resolves to
+func (r *Repo) Head() (string, error) {
+	return r.ResolveRef("HEAD")
+}
+
+// ResolveRef resolves a ref name (HEAD, refs/heads/main, ...) to an object SHA,
+// following symbolic refs and falling back to packed-refs
+func (r *Repo) ResolveRef(name string) (string, error) {
+	for depth := 0; depth < maxSymrefDepth; depth++ {
+		value, err := r.readRef(name)
+		if err != nil {
+			return "", err
+		}
+		target, ok := strings.CutPrefix(value, "ref:")
+		if !ok {
+			return value, nil
+		}
+		name = strings.TrimSpace(target)
+	}
+	return "", fmt.Errorf("%s: too many levels of symbolic refs", name)
+}
+
+// readRef returns the raw value of a loose or packed ref
+func (r *Repo) readRef(name string) (string, error) {
+	// HEAD and other per-worktree refs live in GitDir; branches in the common dir
+	for _, dir := range []string{r.GitDir, r.commonDir()} {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err == nil {
+			return strings.TrimSpace(string(data)), nil
+		}
+	}
+
+	packed, err := r.packedRefs()
+	if err != nil {
+		return "", err
+	}
+	if sha, ok := packed[name]; ok {
+		return sha, nil
+	}
+
+	if name != "HEAD" && strings.HasPrefix(name, "refs/heads/") {
+		return "", ErrNoCommits
+	}
+	return "", fmt.Errorf("ref %s not found", name)
+}
+
+// packedRefs parses the packed-refs file, ignoring peeled tag lines
+func (r *Repo) packedRefs() (map[string]string, error) {
+	refs := make(map[string]string)
+
+	file, err := os.Open(filepath.Join(r.commonDir(), "packed-refs"))
+	if errors.Is(err, os.ErrNotExist) {
+		return refs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sc := bufio.NewScanner(file)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		if sha, name, ok := strings.Cut(line, " "); ok {
+			refs[name] = sha
+		}
+	}
+	return refs, sc.Err()
+}
+
+// Commit holds the parts of a commit object the hooks use
+type Commit struct {
+	SHA     string
+	Tree    string
+	Parents []string
+}
+
+// ReadCommit reads and parses a commit object
+func (r *Repo) ReadCommit(sha string) (*Commit, error) {
+	objType, data, err := r.ReadObject(sha)
+	if err != nil {
+		return nil, err
+	}
+	if objType != TypeCommit {
+		return nil, fmt.Errorf("object %s is a %s, not a commit", sha, objType)
+	}
+
+	commit := &Commit{SHA: sha}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			// Headers end at the first blank line
+			break
+		}
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "tree":
+			commit.Tree = value
+		case "parent":
+			commit.Parents = append(commit.Parents, value)
+		}
+	}
+	return commit, nil
+}
+
+// RootCommit follows first parents from HEAD back to the initial commit
+func (r *Repo) RootCommit() (string, error) {
+	sha, err := r.Head()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		commit, err := r.ReadCommit(sha)
+		if err != nil {
+			return "", err
+		}
+		if len(commit.Parents) == 0 {
+			return sha, nil
+		}
+		sha = commit.Parents[0]
+	}
+}
@@ -0,0 +1,170 @@
+package git
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotRepository is returned when no .git directory is found
+var ErrNotRepository = errors.New("not a git repository")
+
+// Repo is a git repository read directly from its .git directory
+type Repo struct {
+	WorkTree string
+	GitDir   string
+
+	packs []*pack
+}
+
+// Open finds the repository containing dir, walking up to parent directories.
+// A .git file (worktrees, submodules) is followed to its gitdir.
+func Open(dir string) (*Repo, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		dotGit := filepath.Join(abs, ".git")
+		info, err := os.Stat(dotGit)
+		if err == nil {
+			if info.IsDir() {
+				return &Repo{WorkTree: abs, GitDir: dotGit}, nil
+			}
+			gitDir, err := readGitFile(dotGit)
+			if err != nil {
+				return nil, err
+			}
+			return &Repo{WorkTree: abs, GitDir: gitDir}, nil
+		}
+
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return nil, ErrNotRepository
+		}
+		abs = parent
+	}
+}
+
+// readGitFile resolves a ".git" file containing "gitdir: <path>"
+func readGitFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, "gitdir:") {
+		return "", ErrNotRepository
+	}
+	gitDir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+	return gitDir, nil
+}
+
+// commonDir returns the directory holding shared objects, refs and config.
+// For linked worktrees this differs from GitDir.
+func (r *Repo) commonDir() string {
+	data, err := os.ReadFile(filepath.Join(r.GitDir, "commondir"))
+	if err != nil {
+		return r.GitDir
+	}
+	dir := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(r.GitDir, dir)
+	}
+	return dir
+}
+
+// Config holds parsed git config values keyed by "section.subsection.key"
+type Config map[string]string
+
+// Get returns a config value, e.g. Get("remote.origin.url")
+func (c Config) Get(key string) string {
+	return c[key]
+}
+
+// ReadConfig parses the repository's config file
+func (r *Repo) ReadConfig() (Config, error) {
+	file, err := os.Open(filepath.Join(r.commonDir(), "config"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	cfg := Config{}
+	section := ""
+	sc := bufio.NewScanner(file)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = parseSection(line[1 : len(line)-1])
+			continue
+		}
+
+		key, val, ok := strings.Cut(line, "=")
+		if !ok {
+			// A bare key means boolean true
+			key, val = line, "true"
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		val = strings.Trim(strings.TrimSpace(val), `"`)
+		cfg[section+"."+key] = val
+	}
+
+	return cfg, sc.Err()
+}
+
+// parseSection turns `remote "origin"` into "remote.origin"
+func parseSection(header string) string {
+	name, sub, ok := strings.Cut(header, " ")
+	if !ok {
+		return strings.ToLower(header)
+	}
+	return strings.ToLower(name) + "." + strings.Trim(strings.TrimSpace(sub), `"`)
+}
+
+// RemoteURL returns the URL of the named remote, or "" if not configured
+func (r *Repo) RemoteURL(name string) string {
+	cfg, err := r.ReadConfig()
+	if err != nil {
+		return ""
+	}
+	return cfg.Get("remote." + name + ".url")
+}
+
+// NormalizeURL reduces remote URL variants (https, ssh, scp-style, with or
+// without user and .git suffix) to "host/path"
+func NormalizeURL(remote string) string {
+	u := strings.TrimSpace(remote)
+	if u == "" {
+		return ""
+	}
+
+	if i := strings.Index(u, "://"); i >= 0 {
+		u = u[i+3:]
+	} else if host, path, ok := strings.Cut(u, ":"); ok && !strings.Contains(host, "/") {
+		// scp-style: user@host:path
+		u = host + "/" + path
+	}
+
+	if at := strings.Index(u, "@"); at >= 0 && at < strings.Index(u+"/", "/") {
+		u = u[at+1:]
+	}
+
+	host, path, _ := strings.Cut(u, "/")
+	if h, _, ok := strings.Cut(host, ":"); ok {
+		// Drop ports so ssh and https URLs of the same host agree
+		host = h
+	}
+	path = strings.TrimSuffix(strings.TrimSuffix(path, "/"), ".git")
+	return strings.ToLower(host) + "/" + path
+}
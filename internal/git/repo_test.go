@@ -0,0 +1,161 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runGit runs git in dir and returns its trimmed output
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE=2026-01-01T00:00:00Z", "GIT_COMMITTER_DATE=2026-01-01T00:00:00Z")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// newTestRepo creates a repository with an origin remote and one commit
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	runGit(t, dir, "config", "user.email", "dev@example.com")
+	runGit(t, dir, "config", "user.name", "Dev")
+	runGit(t, dir, "remote", "add", "origin", "git@github.com:acme/app.git")
+	commitFile(t, dir, "README.md", "# app\n", "initial")
+	return dir
+}
+
+// writeTestFile writes a file below dir, creating parent directories
+func writeTestFile(t *testing.T, dir, path, content string) {
+	t.Helper()
+	full := filepath.Join(dir, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// commitFile writes a file and commits it
+func commitFile(t *testing.T, dir, path, content, message string) {
+	t.Helper()
+	writeTestFile(t, dir, path, content)
+	runGit(t, dir, "add", path)
+	runGit(t, dir, "commit", "-q", "-m", message)
+}
+
+// lines returns n numbered lines, with line edited changed
+func lines(n, edited int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		if i == edited {
+			fmt.Fprintf(&sb, "edited line %d\n", i)
+		} else {
+			fmt.Fprintf(&sb, "line %d of a file large enough to be stored as a delta\n", i)
+		}
+	}
+	return sb.String()
+}
+
+func TestRepoReadsPackedObjects(t *testing.T) {
+	dir := newTestRepo(t)
+	root := runGit(t, dir, "rev-parse", "HEAD")
+
+	// Successive versions of one file become deltas against each other once packed
+	for i := 0; i < 5; i++ {
+		commitFile(t, dir, "src/main.go", lines(200, i*40), fmt.Sprintf("edit %d", i))
+	}
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T)
+	}{
+		{"loose objects", func(t *testing.T) {}},
+		{"after gc", func(t *testing.T) {
+			runGit(t, dir, "gc", "-q", "--aggressive")
+			if _, err := os.Stat(filepath.Join(dir, ".git", "refs", "heads", "feature")); !os.IsNotExist(err) {
+				t.Fatal("gc left the branch ref loose")
+			}
+			packs, _ := filepath.Glob(filepath.Join(dir, ".git", "objects", "pack", "*.idx"))
+			if len(packs) != 1 || !strings.Contains(runGit(t, dir, "verify-pack", "-v", packs[0]), "chain length") {
+				t.Fatal("gc did not write a pack with deltas")
+			}
+		}},
+		{"loose commit on top of a pack", func(t *testing.T) {
+			commitFile(t, dir, "src/util/util.go", "package util\n", "add util")
+		}},
+		{"after second gc", func(t *testing.T) {
+			commitFile(t, dir, "src/main.go", lines(200, 199), "edit last line")
+			runGit(t, dir, "gc", "-q")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup(t)
+
+			repo, err := Open(filepath.Join(dir, "src"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			head, err := repo.Head()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := runGit(t, dir, "rev-parse", "HEAD"); head != want {
+				t.Errorf("Head() = %s, want %s", head, want)
+			}
+			if ref, _ := repo.HeadRef(); ref != "refs/heads/feature" {
+				t.Errorf("HeadRef() = %q, want refs/heads/feature", ref)
+			}
+
+			identity, err := repo.ProjectIdentity()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if identity.RootCommit != root || identity.RemoteURL != "github.com/acme/app" {
+				t.Errorf("identity = %+v, want root %s and github.com/acme/app", identity, root)
+			}
+
+			files, err := repo.HeadFiles()
+			if err != nil {
+				t.Fatal(err)
+			}
+			listed := strings.Split(runGit(t, dir, "ls-tree", "-r", "HEAD"), "\n")
+			if len(files) != len(listed) {
+				t.Errorf("HeadFiles() has %d files, git lists %d", len(files), len(listed))
+			}
+			for _, line := range listed {
+				// <mode> blob <sha>\t<path>
+				meta, path, _ := strings.Cut(line, "\t")
+				sha := strings.Fields(meta)[2]
+				if files[path].SHA != sha {
+					t.Errorf("%s: SHA %s, want %s", path, files[path].SHA, sha)
+					continue
+				}
+				objType, data, err := repo.ReadObject(sha)
+				if err != nil {
+					t.Fatalf("ReadObject(%s): %v", path, err)
+				}
+				if objType != TypeBlob || BlobSHA(data) != sha {
+					t.Errorf("%s: read %s with SHA %s, want blob %s", path, objType, BlobSHA(data), sha)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package git
+
+import (
+	"regexp"
+	"strings"
+)
+
+// trailerLine matches a "Key: value" trailer
+var trailerLine = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9-]*: `)
+
+// CommentChar returns the comment character git uses in commit messages
+func (r *Repo) CommentChar() string {
+	cfg, err := r.ReadConfig()
+	if err != nil {
+		return "#"
+	}
+	c := cfg.Get("core.commentchar")
+	if c == "" || c == "auto" {
+		return "#"
+	}
+	return c
+}
+
+// HasContent reports whether a commit message has any text besides comments and blank lines
+func HasContent(message, commentChar string) bool {
+	for _, line := range strings.Split(message, "\n") {
+		if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, commentChar) {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTrailers appends trailers to a commit message, skipping ones already
+// present. They go after the last non-comment line, joining an existing
+// trailer block or starting a new paragraph; trailing comments are kept.
+func AddTrailers(message string, trailers []string, commentChar string) string {
+	lines := strings.Split(strings.TrimRight(message, "\n"), "\n")
+
+	// Split off the comment block git appends for the editor
+	end := len(lines)
+	for end > 0 && (strings.TrimSpace(lines[end-1]) == "" || strings.HasPrefix(lines[end-1], commentChar)) {
+		end--
+	}
+	body, tail := lines[:end], lines[end:]
+
+	present := make(map[string]bool, len(body))
+	for _, line := range body {
+		present[line] = true
+	}
+	var missing []string
+	for _, t := range trailers {
+		if !present[t] {
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) == 0 {
+		return message
+	}
+
+	// Trailers must be separated from the message body by a blank line,
+	// unless the last paragraph already is a trailer block
+	lastParagraph := len(body)
+	for lastParagraph > 0 && strings.TrimSpace(body[lastParagraph-1]) != "" {
+		lastParagraph--
+	}
+	isTrailerBlock := lastParagraph > 0 && lastParagraph < len(body)
+	for _, line := range body[lastParagraph:] {
+		if !trailerLine.MatchString(line) {
+			isTrailerBlock = false
+		}
+	}
+
+	out := append([]string{}, body...)
+	if !isTrailerBlock {
+		out = append(out, "")
+	}
+	out = append(out, missing...)
+	out = append(out, tail...)
+	return strings.Join(out, "\n") + "\n"
+}
@@ -0,0 +1,83 @@
+package git
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// Tree entry modes
+const (
+	ModeTree    = 0o040000
+	ModeSymlink = 0o120000
+	ModeGitlink = 0o160000
+)
+
+// TreeEntry is one blob (or submodule) in a flattened tree
+type TreeEntry struct {
+	SHA  string
+	Mode uint32
+}
+
+// ReadTreeFiles returns every non-tree entry under the tree, keyed by slash-separated path
+func (r *Repo) ReadTreeFiles(treeSHA string) (map[string]TreeEntry, error) {
+	files := make(map[string]TreeEntry)
+	if err := r.walkTree(treeSHA, "", files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// walkTree adds the entries of one tree object, recursing into subtrees
+func (r *Repo) walkTree(sha, prefix string, files map[string]TreeEntry) error {
+	objType, data, err := r.ReadObject(sha)
+	if err != nil {
+		return err
+	}
+	if objType != TypeTree {
+		return fmt.Errorf("object %s is a %s, not a tree", sha, objType)
+	}
+
+	// Entries: "<octal mode> <name>\0<20-byte sha>"
+	for len(data) > 0 {
+		header, rest, ok := bytes.Cut(data, []byte{0})
+		if !ok || len(rest) < 20 {
+			return fmt.Errorf("tree %s: malformed entry", sha)
+		}
+		modeStr, name, ok := bytes.Cut(header, []byte{' '})
+		if !ok {
+			return fmt.Errorf("tree %s: malformed entry", sha)
+		}
+		mode, err := strconv.ParseUint(string(modeStr), 8, 32)
+		if err != nil {
+			return fmt.Errorf("tree %s: %w", sha, err)
+		}
+		entrySHA := hex.EncodeToString(rest[:20])
+		data = rest[20:]
+
+		path := prefix + string(name)
+		if mode == ModeTree {
+			if err := r.walkTree(entrySHA, path+"/", files); err != nil {
+				return err
+			}
+			continue
+		}
+		files[path] = TreeEntry{SHA: entrySHA, Mode: uint32(mode)}
+	}
+
+	return nil
+}
+
+// HeadFiles returns the files of the commit HEAD points to
+func (r *Repo) HeadFiles() (map[string]TreeEntry, error) {
+	sha, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+	commit, err := r.ReadCommit(sha)
+	if err != nil {
+		return nil, err
+	}
+	return r.ReadTreeFiles(commit.Tree)
+}
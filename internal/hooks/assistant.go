@@ -0,0 +1,113 @@
+package hooks
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"codetracker-hooks/internal/api"
+	"codetracker-hooks/internal/config"
+	"codetracker-hooks/internal/transcript"
+)
+
+// assistantMessages collects the model, stop reason, token usage and,
+// optionally, thinking text of each assistant response. Claude Code writes a
+// response as several entries sharing one message ID; they are merged, keeping
+// the usage of the last one.
+func assistantMessages(entries []*transcript.Entry, includeThinking bool, maxThinking int) []api.AssistantMessage {
+	var messages []api.AssistantMessage
+	index := make(map[string]int)
+	thinking := make(map[int][]string)
+
+	for _, entry := range entries {
+		if entry.Type != "assistant" || entry.Message == nil {
+			continue
+		}
+		msg := entry.Message
+
+		// Entries without a message ID are responses of their own
+		key := msg.ID
+		if key == "" {
+			key = "uuid:" + entry.UUID
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(messages)
+			index[key] = i
+			messages = append(messages, api.AssistantMessage{MessageID: msg.ID, UUID: entry.UUID})
+		}
+
+		m := &messages[i]
+		if msg.Model != "" {
+			m.Model = msg.Model
+		}
+		if msg.StopReason != "" {
+			m.StopReason = msg.StopReason
+		}
+		if msg.Usage != nil {
+			m.Usage = &api.TokenUsage{
+				InputTokens:              msg.Usage.InputTokens,
+				OutputTokens:             msg.Usage.OutputTokens,
+				CacheCreationInputTokens: msg.Usage.CacheCreationInputTokens,
+				CacheReadInputTokens:     msg.Usage.CacheReadInputTokens,
+			}
+		}
+
+		if includeThinking {
+			for _, block := range entry.Blocks() {
+				if block.Type == "thinking" && block.Thinking != "" {
+					thinking[i] = append(thinking[i], block.Thinking)
+				}
+			}
+		}
+	}
+
+	for i, parts := range thinking {
+		messages[i].Thinking, messages[i].ThinkingTruncated = truncate(strings.Join(parts, "\n\n"), maxThinking)
+	}
+
+	return messages
+}
+
+// interactionUsage totals the token usage of messages and estimates its cost
+// from the configured pricing. It returns nil when no message reports usage.
+func interactionUsage(cfg *config.Config, messages []api.AssistantMessage) *api.InteractionUsage {
+	usage := &api.InteractionUsage{}
+	cost, priced := 0.0, false
+	unpriced := make(map[string]bool)
+
+	for _, m := range messages {
+		if m.Usage == nil {
+			continue
+		}
+		usage.Messages++
+		usage.InputTokens += m.Usage.InputTokens
+		usage.OutputTokens += m.Usage.OutputTokens
+		usage.CacheCreationInputTokens += m.Usage.CacheCreationInputTokens
+		usage.CacheReadInputTokens += m.Usage.CacheReadInputTokens
+
+		price, ok := cfg.PriceFor(m.Model)
+		if !ok {
+			unpriced[m.Model] = true
+			continue
+		}
+		priced = true
+		cost += (float64(m.Usage.InputTokens)*price.Input +
+			float64(m.Usage.OutputTokens)*price.Output +
+			float64(m.Usage.CacheCreationInputTokens)*price.CacheWrite +
+			float64(m.Usage.CacheReadInputTokens)*price.CacheRead) / 1e6
+	}
+
+	if usage.Messages == 0 {
+		return nil
+	}
+	if priced {
+		cost = math.Round(cost*1e6) / 1e6
+		usage.EstimatedCostUSD = &cost
+	}
+	for model := range unpriced {
+		usage.UnpricedModels = append(usage.UnpricedModels, model)
+	}
+	sort.Strings(usage.UnpricedModels)
+	return usage
+}
@@ -0,0 +1,58 @@
+package hooks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"codetracker-hooks/internal/api"
+	"codetracker-hooks/internal/transcript"
+)
+
+// uploadImages uploads the inline images of a user entry to the primary
+// project and records their attachment IDs. attachments holds the entry's
+// images and documents in block order, as returned by userContent. Images
+// found in uploaded are not uploaded again; new uploads are added to it.
+func uploadImages(e *env, sessionID string, entry *transcript.Entry, attachments []api.Attachment, uploaded map[string]string) {
+	i := 0
+	for _, block := range entry.Blocks() {
+		if block.Type != "image" && block.Type != "document" {
+			continue
+		}
+		if i >= len(attachments) {
+			return
+		}
+		a := &attachments[i]
+		i++
+
+		if block.Type != "image" || block.Source == nil || block.Source.Type != "base64" {
+			continue
+		}
+		if a.Size > e.cfg.ConversationTracking.MaxImageBytes {
+			continue
+		}
+		key := imageKey(entry.UUID, block.Source.Data)
+		if id, ok := uploaded[key]; ok {
+			a.AttachmentID = id
+			continue
+		}
+
+		resp, err := e.client.UploadAttachment(&api.UploadAttachmentRequest{
+			ProjectHash: e.primary.ProjectHash,
+			SessionID:   sessionID,
+			UUID:        entry.UUID,
+			MediaType:   block.Source.MediaType,
+			Data:        block.Source.Data,
+		})
+		if err != nil {
+			continue
+		}
+		a.AttachmentID = resp.AttachmentID.String()
+		uploaded[key] = a.AttachmentID
+	}
+}
+
+// imageKey identifies an image of an entry across re-reads of the transcript
+func imageKey(uuid, data string) string {
+	sum := sha256.Sum256([]byte(uuid + "\n" + data))
+	return hex.EncodeToString(sum[:])
+}
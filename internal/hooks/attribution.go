@@ -0,0 +1,158 @@
+package hooks
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"codetracker-hooks/internal/cache"
+	"codetracker-hooks/internal/config"
+	"codetracker-hooks/internal/diff"
+	"codetracker-hooks/internal/transcript"
+)
+
+// fileEditTools are tools whose input names the file they modify
+var fileEditTools = map[string]bool{
+	"Edit":         true,
+	"MultiEdit":    true,
+	"Write":        true,
+	"NotebookEdit": true,
+}
+
+// toolInput holds the parts of a tool_use input relevant for attribution
+type toolInput struct {
+	ID        string
+	Name      string
+	FilePaths []string
+	Command   string
+}
+
+// collectToolInputs extracts tool_use inputs from an assistant entry
+func collectToolInputs(entry *transcript.Entry, projectRoot string) []toolInput {
+	if entry.Type != "assistant" {
+		return nil
+	}
+
+	var inputs []toolInput
+	for _, block := range entry.Blocks() {
+		if block.Type != "tool_use" {
+			continue
+		}
+
+		ti := toolInput{ID: block.ID, Name: block.Name}
+		for _, key := range []string{"file_path", "notebook_path"} {
+			if p, ok := block.Input[key].(string); ok && p != "" {
+				ti.FilePaths = append(ti.FilePaths, relativeToRoot(projectRoot, p))
+			}
+		}
+		ti.Command, _ = block.Input["command"].(string)
+		inputs = append(inputs, ti)
+	}
+
+	return inputs
+}
+
+// attributeChanges tags changes without a recorded source with the tool call
+// that most likely made it. File-editing tools naming the path win over Bash
+// commands mentioning it. Changes recorded as human are only retagged when a
+// tool call matches; other changes with no match are tagged as unattributed.
+func attributeChanges(changes []*diff.Change, inputs []toolInput) {
+	for _, change := range changes {
+		if change.Source != "" && change.Source != diff.SourceHuman {
+			continue
+		}
+		if source, ids := matchToolCall(change.FilePath, inputs); source != "" {
+			change.Source = source
+			change.ToolUseIDs = ids
+		} else if change.Source == "" {
+			change.Source = diff.SourceUnattributed
+		}
+	}
+}
+
+// matchToolCall returns the tool and tool_use IDs that likely changed a file
+func matchToolCall(filePath string, inputs []toolInput) (string, []string) {
+	var source string
+	var ids []string
+	for _, ti := range inputs {
+		if !fileEditTools[ti.Name] {
+			continue
+		}
+		for _, p := range ti.FilePaths {
+			if p == filePath {
+				source = ti.Name
+				ids = append(ids, ti.ID)
+				break
+			}
+		}
+	}
+	if source != "" {
+		return source, ids
+	}
+
+	for _, ti := range inputs {
+		if ti.Name == "Bash" && commandMentions(ti.Command, filePath) {
+			source = ti.Name
+			ids = append(ids, ti.ID)
+		}
+	}
+	return source, ids
+}
+
+// recordSources adds the sources of changes to the ones recorded earlier in the
+// prompt. A file the assistant changed keeps that attribution when it is also
+// changed by hand; tool_use IDs accumulate. Changes without a source are left
+// for stop to attribute.
+func recordSources(sources map[string]*diff.Attribution, changes []*diff.Change) map[string]*diff.Attribution {
+	if sources == nil {
+		sources = make(map[string]*diff.Attribution)
+	}
+	for _, change := range changes {
+		if change.Source == "" {
+			continue
+		}
+		prev, ok := sources[change.FilePath]
+		switch {
+		case !ok || prev.Source == diff.SourceHuman:
+			sources[change.FilePath] = &diff.Attribution{Source: change.Source, ToolUseIDs: change.ToolUseIDs}
+		case change.Source != diff.SourceHuman:
+			prev.ToolUseIDs = append(prev.ToolUseIDs, change.ToolUseIDs...)
+		}
+	}
+	return sources
+}
+
+// applySources tags changes with the sources recorded by the tool and subagent hooks
+func applySources(changes []*diff.Change, sources map[string]*diff.Attribution) {
+	for _, change := range changes {
+		if a, ok := sources[change.FilePath]; ok {
+			change.Source = a.Source
+			change.ToolUseIDs = append([]string(nil), a.ToolUseIDs...)
+		}
+	}
+}
+
+// checkpointSources returns the sources recorded in the current prompt's tool checkpoint
+func checkpointSources() map[string]*diff.Attribution {
+	checkpoint, _ := cache.LoadLastSnapshot(config.CheckpointFile())
+	if checkpoint == nil {
+		return nil
+	}
+	return checkpoint.Sources
+}
+
+// commandMentions reports whether a shell command refers to the given relative path,
+// either in full or by its file name as a separate word
+func commandMentions(command, filePath string) bool {
+	if command == "" {
+		return false
+	}
+	if strings.Contains(command, filePath) {
+		return true
+	}
+	re, err := regexp.Compile(`(^|[\s'"=/])` + regexp.QuoteMeta(path.Base(filePath)) + `($|[\s'";|&>)])`)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(command)
+}
@@ -0,0 +1,175 @@
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"codetracker-hooks/internal/api"
+	"codetracker-hooks/internal/cache"
+	"codetracker-hooks/internal/config"
+	"codetracker-hooks/internal/diff"
+	"codetracker-hooks/internal/git"
+	"codetracker-hooks/internal/scanner"
+	"codetracker-hooks/internal/session"
+)
+
+// errInvalidCredentials is returned when credentials lack required fields
+var errInvalidCredentials = errors.New("invalid credentials")
+
+// errNotConfigured is returned in projects without a .codetracker/config.json
+var errNotConfigured = errors.New("project not configured")
+
+// env holds the configuration shared by all hook handlers
+type env struct {
+	cfg    *config.Config
+	creds  *config.Credentials
+	client *api.Client
+
+	// primary is the project that owns the session and the conversation
+	primary config.ProjectTarget
+	// remoteURL is the origin remote of the project, used to match project mappings
+	remoteURL string
+}
+
+// loadEnv loads config and credentials and creates an API client
+func loadEnv() (*env, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return newEnv(cfg)
+}
+
+// loadConfig loads the config of a tracked project. Projects without their
+// own config file are left untouched. Config problems are written to the
+// config issues log instead of being dropped.
+func loadConfig() (*config.Config, error) {
+	if _, err := os.Stat(config.ConfigFile()); err != nil {
+		return nil, errNotConfigured
+	}
+
+	cfg, issues := config.Validate()
+	logConfigIssues(issues)
+	if cfg == nil {
+		return nil, errors.New("config could not be loaded")
+	}
+	return cfg, nil
+}
+
+// newEnv loads credentials for cfg and creates an API client
+func newEnv(cfg *config.Config) (*env, error) {
+	creds, err := config.LoadCredentials(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteURL := ""
+	if len(creds.Projects) > 0 {
+		remoteURL = originURL(config.GetProjectRoot())
+	}
+	primary := creds.PrimaryTarget(remoteURL)
+	if primary.APIKey == "" {
+		return nil, errInvalidCredentials
+	}
+
+	return &env{
+		cfg:       cfg,
+		creds:     creds,
+		client:    api.NewClient(cfg.ServerURL, primary.APIKey),
+		primary:   primary,
+		remoteURL: remoteURL,
+	}, nil
+}
+
+// resolveProject fills in the primary project, when none is configured, with
+// the one registered for the git repository. Handlers call it once they know
+// they will send something, so hooks that record nothing never register.
+func (e *env) resolveProject() error {
+	if e.primary.ProjectHash != "" {
+		return nil
+	}
+	hash, err := identifyProject(e.cfg, e.client)
+	if err != nil {
+		return fmt.Errorf("no project configured and none registered for the repository: %w", err)
+	}
+	e.creds.CurrentProjectHash = hash
+	e.creds.Sources["current_project_hash"] = config.SourceGitIdentity
+	e.primary = e.creds.PrimaryTarget(e.remoteURL)
+	return nil
+}
+
+// logConfigIssues writes the current config issues to the log, or removes it
+// when there are none. The log is left alone while the issues are unchanged,
+// so its header keeps the time they were first seen.
+func logConfigIssues(issues []config.Issue) {
+	if len(issues) == 0 {
+		os.Remove(config.ConfigIssuesFile())
+		return
+	}
+
+	var body strings.Builder
+	for _, issue := range issues {
+		body.WriteString(issue.String() + "\n")
+	}
+	if data, err := os.ReadFile(config.ConfigIssuesFile()); err == nil {
+		if _, logged, _ := strings.Cut(string(data), "\n"); logged == body.String() {
+			return
+		}
+	}
+
+	if err := os.MkdirAll(config.CacheDir(), 0755); err != nil {
+		return
+	}
+	header := fmt.Sprintf("# %s - run 'codetracker doctor' for details\n", time.Now().UTC().Format(time.RFC3339))
+	os.WriteFile(config.ConfigIssuesFile(), []byte(header+body.String()), 0644)
+}
+
+// scanProject scans tracked files under the project root
+func scanProject(cfg *config.Config) (map[string]*scanner.FileInfo, error) {
+	s, err := scanner.NewScanner(config.GetProjectRoot(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	return s.Scan()
+}
+
+// gitMetadata returns the git state of the project, or nil outside a
+// repository. Only the prompt and stop hooks read it; the hooks in between
+// reuse the state saved with the session.
+func gitMetadata() *git.Metadata {
+	repo, err := git.Open(config.GetProjectRoot())
+	if err != nil {
+		return nil
+	}
+	meta, err := repo.Metadata()
+	if err != nil {
+		return nil
+	}
+	return meta
+}
+
+// loadCheckpoint returns the file state and snapshot IDs that in-prompt records
+// build on: the last tool/subagent checkpoint, or the pre-prompt snapshot.
+// projectIDs holds the matching snapshot IDs of secondary projects.
+func loadCheckpoint(sessionData *session.SessionData) (files map[string]*diff.SnapshotFileInfo, parentSnapshotID string, projectIDs map[string]string) {
+	baseline, _ := cache.LoadLastSnapshot(config.CheckpointFile())
+	if baseline == nil {
+		baseline, _ = cache.LoadLastSnapshot(config.LastSnapshotFile())
+	}
+
+	parentSnapshotID = sessionData.PreSnapshotID
+	projectIDs = sessionData.ProjectPreSnapshotIDs
+	if baseline == nil {
+		return nil, parentSnapshotID, projectIDs
+	}
+	if baseline.SnapshotID != "" {
+		parentSnapshotID = baseline.SnapshotID
+	}
+	if baseline.ProjectSnapshotIDs != nil {
+		projectIDs = baseline.ProjectSnapshotIDs
+	}
+	return baseline.Files, parentSnapshotID, projectIDs
+}
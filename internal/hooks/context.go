@@ -0,0 +1,71 @@
+package hooks
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"codetracker-hooks/internal/cache"
+	"codetracker-hooks/internal/config"
+	"codetracker-hooks/internal/diff"
+	"codetracker-hooks/internal/merge"
+	"codetracker-hooks/internal/scanner"
+)
+
+// changeVerbs describes change types in context summaries
+var changeVerbs = map[diff.ChangeType]string{
+	diff.Added:    "added",
+	diff.Modified: "modified",
+	diff.Deleted:  "deleted",
+}
+
+// humanChangesContext builds UserPromptSubmit output telling Claude which files
+// the developer changed since the last snapshot. Returns nil if there is nothing to report.
+func humanChangesContext(changes []*diff.Change, currentFiles map[string]*scanner.FileInfo, maxFiles int) *Output {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	sorted := make([]*diff.Change, len(changes))
+	copy(sorted, changes)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].FilePath < sorted[j].FilePath
+	})
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "CodeTracker: the developer changed %d file(s) since the last prompt:\n", len(sorted))
+	for i, change := range sorted {
+		if maxFiles > 0 && i >= maxFiles {
+			fmt.Fprintf(&sb, "- ... and %d more\n", len(sorted)-maxFiles)
+			break
+		}
+		fmt.Fprintf(&sb, "- %s: %s%s\n", changeVerbs[change.Type], change.FilePath, lineStats(change, currentFiles))
+	}
+
+	return &Output{
+		HookSpecificOutput: &HookSpecificOutput{
+			HookEventName:     EventUserPromptSubmit,
+			AdditionalContext: strings.TrimSuffix(sb.String(), "\n"),
+		},
+	}
+}
+
+// lineStats returns " (+added -removed)" for a modified file when its previous content is cached
+func lineStats(change *diff.Change, currentFiles map[string]*scanner.FileInfo) string {
+	if change.Type != diff.Modified {
+		return ""
+	}
+	current, ok := currentFiles[change.FilePath]
+	if !ok {
+		return ""
+	}
+	previous, err := cache.LoadBlob(config.ObjectsDir(), change.PreviousHash)
+	if err != nil {
+		return ""
+	}
+
+	prevLines := merge.SplitLines(previous)
+	curLines := merge.SplitLines(current.Content)
+	common := len(merge.Matches(prevLines, curLines))
+	return fmt.Sprintf(" (+%d -%d)", len(curLines)-common, len(prevLines)-common)
+}
@@ -0,0 +1,67 @@
+package hooks
+
+import (
+	"os"
+	"time"
+
+	"codetracker-hooks/internal/cache"
+	"codetracker-hooks/internal/config"
+)
+
+const (
+	// blobGCInterval is how often unreferenced blobs are collected when no
+	// interaction record was pruned
+	blobGCInterval = 24 * time.Hour
+	// blobGrace keeps blobs that hooks still running may be about to reference
+	blobGrace = time.Hour
+)
+
+// pruneHistory applies the history retention limits to the local interaction
+// records and removes blobs no longer referenced by a kept record or a cached
+// snapshot
+func pruneHistory(cfg *config.Config) {
+	now := time.Now()
+	maxAge := time.Duration(cfg.History.MaxAgeDays) * 24 * time.Hour
+	pruned, _ := cache.PruneInteractions(config.InteractionsDir(), cfg.History.MaxInteractions, maxAge, now)
+
+	stamp := config.LastBlobGCFile()
+	if info, err := os.Stat(stamp); pruned == 0 && err == nil && now.Sub(info.ModTime()) < blobGCInterval {
+		return
+	}
+
+	keep, err := referencedBlobs()
+	if err != nil {
+		return
+	}
+	if _, err := cache.CollectBlobs(config.ObjectsDir(), keep, blobGrace, now); err != nil {
+		return
+	}
+	os.WriteFile(stamp, []byte(now.UTC().Format(time.RFC3339)+"\n"), 0644)
+}
+
+// referencedBlobs returns the hashes of the blobs the kept interaction records,
+// the last snapshot and the tool checkpoint refer to
+func referencedBlobs() (map[string]bool, error) {
+	records, err := cache.ListInteractions(config.InteractionsDir(), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := make(map[string]bool)
+	for _, record := range records {
+		for _, change := range record.Changes {
+			keep[change.Hash] = true
+			keep[change.PreviousHash] = true
+		}
+	}
+	for _, file := range []string{config.LastSnapshotFile(), config.CheckpointFile()} {
+		snapshot, err := cache.LoadLastSnapshot(file)
+		if err != nil {
+			continue
+		}
+		for _, info := range snapshot.Files {
+			keep[info.Hash] = true
+		}
+	}
+	return keep, nil
+}
@@ -0,0 +1,177 @@
+package hooks
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Hook event names as sent by Claude Code in hook_event_name
+const (
+	EventUserPromptSubmit = "UserPromptSubmit"
+	EventStop             = "Stop"
+	EventSubagentStop     = "SubagentStop"
+	EventPreToolUse       = "PreToolUse"
+	EventPostToolUse      = "PostToolUse"
+	EventSessionStart     = "SessionStart"
+	EventSessionEnd       = "SessionEnd"
+)
+
+// Input represents the input from Claude Code for any hook event
+type Input struct {
+	HookEventName  string `json:"hook_event_name"`
+	SessionID      string `json:"session_id"`
+	TranscriptPath string `json:"transcript_path"`
+	Cwd            string `json:"cwd"`
+	Timestamp      string `json:"timestamp"`
+	Prompt         string `json:"prompt"`
+	ToolName       string `json:"tool_name"`
+	ToolUseID      string `json:"tool_use_id"`
+	Source         string `json:"source"`
+	Reason         string `json:"reason"`
+	AgentID        string `json:"agent_id"`
+	StopHookActive bool   `json:"stop_hook_active"`
+
+	// AgentTranscriptPath is the subagent's own transcript, sent with SubagentStop
+	AgentTranscriptPath string `json:"agent_transcript_path"`
+}
+
+// timestamp returns the input timestamp, or the current time if missing
+func (in *Input) timestamp() string {
+	if in.Timestamp != "" {
+		return in.Timestamp
+	}
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// Output is the JSON written to stdout to pass decisions or context back to Claude Code
+type Output struct {
+	Decision           string              `json:"decision,omitempty"`
+	Reason             string              `json:"reason,omitempty"`
+	HookSpecificOutput *HookSpecificOutput `json:"hookSpecificOutput,omitempty"`
+}
+
+// HookSpecificOutput holds event-specific output fields
+type HookSpecificOutput struct {
+	HookEventName     string `json:"hookEventName"`
+	AdditionalContext string `json:"additionalContext,omitempty"`
+}
+
+// Handler handles one hook event. A non-nil Output is written to stdout,
+// even when an error is returned.
+type Handler func(in *Input) (*Output, error)
+
+// handlers maps hook event names to their handlers
+var handlers = map[string]Handler{
+	EventUserPromptSubmit: userPromptSubmit,
+	EventStop:             stop,
+	EventSubagentStop:     subagentStop,
+	EventPreToolUse:       preToolUse,
+	EventPostToolUse:      postToolUse,
+	EventSessionStart:     sessionStart,
+	EventSessionEnd:       sessionEnd,
+}
+
+// aliases maps legacy per-hook binary names to hook events
+var aliases = map[string]string{
+	"user_prompt_submit": EventUserPromptSubmit,
+	"stop":               EventStop,
+	"subagent_stop":      EventSubagentStop,
+	"pre_tool_use":       EventPreToolUse,
+	"post_tool_use":      EventPostToolUse,
+	"session_start":      EventSessionStart,
+	"session_end":        EventSessionEnd,
+}
+
+// GitHook handles a git hook. Git hooks receive their arguments on the
+// command line instead of JSON on stdin.
+type GitHook func(args []string) error
+
+// gitHooks maps git hook names, used as binary names in .git/hooks, to their handlers
+var gitHooks = map[string]GitHook{
+	"post-commit":        postCommit,
+	"prepare-commit-msg": prepareCommitMsg,
+	"commit-msg":         prepareCommitMsg,
+}
+
+// Main runs the hook selected by the input's hook_event_name, falling back to
+// an explicit event argument and then to the binary name. Git hooks are
+// selected by binary name or first argument before stdin is read. It always
+// exits 0 so that Claude Code and git are never blocked.
+func Main(args []string) {
+	defer func() {
+		if r := recover(); r != nil {
+			// Ignore panics
+		}
+		os.Exit(0)
+	}()
+
+	if err := run(args); err != nil {
+		// Silent fail
+		return
+	}
+}
+
+func run(args []string) error {
+	if hook, hookArgs, ok := gitHookFor(args); ok {
+		return hook(hookArgs)
+	}
+
+	// Read input from stdin
+	inputData, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	var input Input
+	if err := json.Unmarshal(inputData, &input); err != nil {
+		return err
+	}
+
+	event := input.HookEventName
+	if event == "" && len(args) > 1 {
+		event = args[1]
+	}
+	if event == "" && len(args) > 0 {
+		event = aliasEvent(args[0])
+	}
+
+	handler, ok := handlers[event]
+	if !ok {
+		return errors.New("unknown hook event: " + event)
+	}
+
+	output, err := handler(&input)
+	if output != nil {
+		if data, marshalErr := json.Marshal(output); marshalErr == nil {
+			os.Stdout.Write(data)
+		}
+	}
+	return err
+}
+
+// gitHookFor selects a git hook by binary name or, failing that, by the first argument
+func gitHookFor(args []string) (GitHook, []string, bool) {
+	if len(args) == 0 {
+		return nil, nil, false
+	}
+	if hook, ok := gitHooks[strings.TrimSuffix(filepath.Base(args[0]), ".exe")]; ok {
+		return hook, args[1:], true
+	}
+	if len(args) > 1 {
+		if hook, ok := gitHooks[args[1]]; ok {
+			return hook, args[2:], true
+		}
+	}
+	return nil, nil, false
+}
+
+// aliasEvent resolves a legacy binary name (argv[0]) to its hook event
+func aliasEvent(argv0 string) string {
+	name := strings.TrimSuffix(filepath.Base(argv0), ".exe")
+	return aliases[name]
+}
@@ -0,0 +1,87 @@
+package hooks
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"codetracker-hooks/internal/api"
+	"codetracker-hooks/internal/cache"
+	"codetracker-hooks/internal/config"
+	"codetracker-hooks/internal/git"
+)
+
+// maxRegisterBackoff caps the wait between failed project registrations
+const maxRegisterBackoff = time.Hour
+
+// registerBackoff returns how long to wait after the given number of
+// consecutive failed registrations: one minute, doubling up to an hour
+func registerBackoff(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	if failures > 7 {
+		return maxRegisterBackoff
+	}
+	return min(time.Minute<<(failures-1), maxRegisterBackoff)
+}
+
+// identifyProject returns the project hash the server registered for the
+// repository's git identity (origin URL and root commit), registering it on
+// first use. The result is cached until the origin or server changes; failed
+// registrations are cached too and not retried until a backoff has passed.
+func identifyProject(cfg *config.Config, client *api.Client) (string, error) {
+	repo, err := git.Open(config.GetProjectRoot())
+	if err != nil {
+		return "", err
+	}
+
+	cacheFile := config.ProjectIdentityFile()
+	remote := git.NormalizeURL(repo.RemoteURL("origin"))
+	cached, err := cache.LoadProjectIdentity(cacheFile)
+	if err != nil || cached.Identity == nil || cached.ServerURL != cfg.ServerURL || cached.Identity.RemoteURL != remote {
+		cached = nil
+	}
+	if cached != nil && cached.ProjectHash != "" {
+		return cached.ProjectHash, nil
+	}
+	if cached != nil && cached.Failures > 0 {
+		failedAt, _ := time.Parse(time.RFC3339, cached.FailedAt)
+		if retry := failedAt.Add(registerBackoff(cached.Failures)); time.Now().Before(retry) {
+			return "", fmt.Errorf("project registration failed (%s); retrying after %s", cached.LastError, retry.Format(time.RFC3339))
+		}
+	}
+
+	identity, err := repo.ProjectIdentity()
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.RegisterProject(&api.RegisterProjectRequest{
+		Identity:   identity.ID,
+		RemoteURL:  identity.RemoteURL,
+		RootCommit: identity.RootCommit,
+		Name:       filepath.Base(repo.WorkTree),
+	})
+	if err != nil {
+		failures := 1
+		if cached != nil && cached.Identity.ID == identity.ID {
+			failures = cached.Failures + 1
+		}
+		cache.SaveProjectIdentity(cacheFile, &cache.ProjectIdentity{
+			Identity:  identity,
+			ServerURL: cfg.ServerURL,
+			Failures:  failures,
+			FailedAt:  time.Now().UTC().Format(time.RFC3339),
+			LastError: err.Error(),
+		})
+		return "", err
+	}
+
+	cache.SaveProjectIdentity(cacheFile, &cache.ProjectIdentity{
+		Identity:    identity,
+		ServerURL:   cfg.ServerURL,
+		ProjectHash: resp.ProjectHash,
+	})
+	return resp.ProjectHash, nil
+}
@@ -0,0 +1,58 @@
+package hooks
+
+import (
+	"fmt"
+
+	"codetracker-hooks/internal/cache"
+	"codetracker-hooks/internal/config"
+	"codetracker-hooks/internal/diff"
+	"codetracker-hooks/internal/policy"
+	"codetracker-hooks/internal/scanner"
+)
+
+// checkPolicies evaluates the configured policies for an event and returns
+// a blocking decision if one is violated, or nil otherwise
+func checkPolicies(cfg *config.Config, event, prompt string, changes []*diff.Change) *Output {
+	if len(cfg.Policies) == 0 {
+		return nil
+	}
+
+	violation := policy.Evaluate(cfg.Policies, &policy.Input{
+		Event:   event,
+		Prompt:  prompt,
+		Changes: changes,
+	})
+	if violation == nil {
+		return nil
+	}
+
+	return &Output{
+		Decision: "block",
+		Reason:   fmt.Sprintf("CodeTracker policy %q: %s", violation.Rule, violation.Reason),
+	}
+}
+
+// stopPolicies evaluates Stop policies against the changes since the prompt
+// was submitted, leaving out the ones the tool hooks recorded as the
+// developer's own edits
+func stopPolicies(cfg *config.Config, currentFiles map[string]*scanner.FileInfo) *Output {
+	baseline, _ := cache.LoadLastSnapshot(config.PolicyBaselineFile())
+	if baseline == nil {
+		return nil
+	}
+	changes := diff.CalculateChanges(currentFiles, baseline.Files)
+	applySources(changes, checkpointSources())
+	return checkPolicies(cfg, EventStop, "", assistantChanges(changes))
+}
+
+// assistantChanges drops changes known to be the developer's own edits,
+// keeping the ones the assistant made or may have made
+func assistantChanges(changes []*diff.Change) []*diff.Change {
+	var kept []*diff.Change
+	for _, change := range changes {
+		if change.Source != diff.SourceHuman {
+			kept = append(kept, change)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,130 @@
+package hooks
+
+import (
+	"path/filepath"
+	"strings"
+
+	"codetracker-hooks/internal/api"
+	"codetracker-hooks/internal/cache"
+	"codetracker-hooks/internal/config"
+	"codetracker-hooks/internal/git"
+	"codetracker-hooks/internal/provenance"
+	"codetracker-hooks/internal/scanner"
+)
+
+// maxLinkedInteractions bounds how many recent interactions a commit is compared against
+const maxLinkedInteractions = 50
+
+// postCommit reports which recorded interactions the new HEAD commit contains
+func postCommit(args []string) error {
+	e, err := loadEnv()
+	if err != nil {
+		return err
+	}
+
+	repo, err := git.Open(config.GetProjectRoot())
+	if err != nil {
+		return err
+	}
+	sha, err := repo.Head()
+	if err != nil {
+		return err
+	}
+	commit, err := repo.ReadCommit(sha)
+	if err != nil {
+		return err
+	}
+
+	changes, err := commitChanges(repo, e.cfg, commit)
+	if err != nil || len(changes) == 0 {
+		return err
+	}
+
+	records, err := recentInteractions()
+	if err != nil {
+		return err
+	}
+	result := provenance.Analyze(changes, records, config.ObjectsDir())
+	if len(result.Links) == 0 {
+		return nil
+	}
+	if err := e.resolveProject(); err != nil {
+		return err
+	}
+
+	req := &api.CommitLinksRequest{
+		ProjectHash:    e.primary.ProjectHash,
+		CommitSHA:      sha,
+		ParentSHAs:     commit.Parents,
+		FilesChanged:   result.Files,
+		LinesAdded:     result.LinesAdded,
+		AssistantLines: result.AssistantLines,
+	}
+	if ref, err := repo.HeadRef(); err == nil {
+		req.Branch = strings.TrimPrefix(ref, "refs/heads/")
+	}
+	for _, link := range result.Links {
+		req.Interactions = append(req.Interactions, api.CommitInteractionLink{
+			SnapshotID:     link.SnapshotID,
+			PreSnapshotID:  link.PreSnapshotID,
+			Files:          link.Files,
+			AssistantLines: link.AssistantLines,
+			FileOverlap:    link.FileOverlap,
+			LineOverlap:    link.LineOverlap,
+		})
+	}
+
+	return e.client.SendCommitLinks(req)
+}
+
+// commitChanges returns the tracked files a commit changed relative to its first parent
+func commitChanges(repo *git.Repo, cfg *config.Config, commit *git.Commit) ([]provenance.FileChange, error) {
+	after, err := repo.ReadTreeFiles(commit.Tree)
+	if err != nil {
+		return nil, err
+	}
+
+	before := map[string]git.TreeEntry{}
+	if len(commit.Parents) > 0 {
+		parent, err := repo.ReadCommit(commit.Parents[0])
+		if err != nil {
+			return nil, err
+		}
+		if before, err = repo.ReadTreeFiles(parent.Tree); err != nil {
+			return nil, err
+		}
+	}
+
+	return trackedChanges(repo, cfg, before, after)
+}
+
+// trackedChanges diffs two git file sets, keeping files under the project root that the scanner tracks
+func trackedChanges(repo *git.Repo, cfg *config.Config, before, after map[string]git.TreeEntry) ([]provenance.FileChange, error) {
+	s, err := scanner.NewScanner(config.GetProjectRoot(), cfg)
+	if err != nil {
+		return nil, err
+	}
+	return provenance.Changes(repo, before, after, projectPrefix(repo), s.Tracks)
+}
+
+// projectPrefix returns the project root relative to the work tree, as "" or "dir/"
+func projectPrefix(repo *git.Repo) string {
+	root, err := filepath.EvalSymlinks(config.GetProjectRoot())
+	if err != nil {
+		return ""
+	}
+	workTree, err := filepath.EvalSymlinks(repo.WorkTree)
+	if err != nil {
+		return ""
+	}
+	rel, err := filepath.Rel(workTree, root)
+	if err != nil || rel == "." {
+		return ""
+	}
+	return filepath.ToSlash(rel) + "/"
+}
+
+// recentInteractions returns the most recent locally recorded interactions
+func recentInteractions() ([]*cache.InteractionRecord, error) {
+	return cache.ListInteractions(config.InteractionsDir(), maxLinkedInteractions)
+}
@@ -0,0 +1,91 @@
+package hooks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"codetracker-hooks/internal/config"
+	"codetracker-hooks/internal/git"
+	"codetracker-hooks/internal/provenance"
+)
+
+// Commit message trailers
+const (
+	trailerInteraction    = "CodeTracker-Interaction"
+	trailerAssistantLines = "CodeTracker-Assistant-Lines"
+)
+
+// prepareCommitMsg appends CodeTracker trailers to the commit message when the
+// staged changes overlap the last recorded interaction. args[0] is the message file.
+// Empty messages are left alone so git still aborts them; installed as
+// commit-msg too, it annotates messages written in the editor.
+func prepareCommitMsg(args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing commit message file")
+	}
+	msgFile := args[0]
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	repo, err := git.Open(config.GetProjectRoot())
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(msgFile)
+	if err != nil {
+		return err
+	}
+	commentChar := repo.CommentChar()
+	if !git.HasContent(string(data), commentChar) {
+		return nil
+	}
+
+	records, err := recentInteractions()
+	if err != nil || len(records) == 0 {
+		return err
+	}
+
+	changes, err := stagedChanges(repo, cfg)
+	if err != nil || len(changes) == 0 {
+		return err
+	}
+
+	result := provenance.Analyze(changes, records[:1], config.ObjectsDir())
+	if len(result.Links) == 0 {
+		return nil
+	}
+
+	trailers := []string{
+		fmt.Sprintf("%s: %s", trailerInteraction, result.Links[0].SnapshotID),
+		fmt.Sprintf("%s: %d/%d (%.1f%%)", trailerAssistantLines, result.AssistantLines, result.LinesAdded, result.AssistantRatio()),
+	}
+	message := git.AddTrailers(string(data), trailers, commentChar)
+	if message == string(data) {
+		return nil
+	}
+	return os.WriteFile(msgFile, []byte(message), 0644)
+}
+
+// stagedChanges returns the tracked files that differ between HEAD and the
+// index being committed, which is a temporary one for "git commit -a" and
+// "git commit <paths>"
+func stagedChanges(repo *git.Repo, cfg *config.Config) ([]provenance.FileChange, error) {
+	staged, err := repo.IndexFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := repo.HeadFiles()
+	if errors.Is(err, git.ErrNoCommits) {
+		head = map[string]git.TreeEntry{}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return trackedChanges(repo, cfg, head, staged)
+}
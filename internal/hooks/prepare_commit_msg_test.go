@@ -0,0 +1,178 @@
+package hooks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"codetracker-hooks/internal/cache"
+	"codetracker-hooks/internal/config"
+	"codetracker-hooks/internal/diff"
+	"codetracker-hooks/internal/scanner"
+)
+
+// gitIn runs git in dir with extra environment variables. The
+// GIT_INDEX_FILE set for the hook under test is not passed on.
+func gitIn(t *testing.T, dir string, env []string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	for _, v := range os.Environ() {
+		if !strings.HasPrefix(v, "GIT_INDEX_FILE=") {
+			cmd.Env = append(cmd.Env, v)
+		}
+	}
+	cmd.Env = append(cmd.Env, env...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sha(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestPrepareCommitMsgTrailers(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	const (
+		base      = "one\ntwo\n"
+		assistant = "one\ntwo\nassistant line\n"
+		human     = "human line\n"
+	)
+
+	dir := t.TempDir()
+	t.Setenv("CLAUDE_PROJECT_DIR", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "xdg"))
+	t.Setenv("GIT_INDEX_FILE", "")
+	if config.GetProjectRoot() != dir {
+		t.Skip("project root already resolved")
+	}
+
+	gitIn(t, dir, nil, "init", "-q")
+	gitIn(t, dir, nil, "config", "user.email", "dev@example.com")
+	gitIn(t, dir, nil, "config", "user.name", "Dev")
+	writeFile(t, filepath.Join(dir, ".gitignore"), ".codetracker/\nxdg/\n")
+	writeFile(t, filepath.Join(dir, "a.txt"), base)
+	writeFile(t, filepath.Join(dir, "h.txt"), "")
+	gitIn(t, dir, nil, "add", ".")
+	gitIn(t, dir, nil, "commit", "-q", "-m", "initial")
+
+	os.MkdirAll(config.TrackerDir(), 0755)
+	writeFile(t, config.ConfigFile(), `{"track_extensions":[".txt"]}`)
+	cache.SaveBlobs(config.ObjectsDir(), map[string]*scanner.FileInfo{
+		"base":      {Hash: sha(base), Content: base},
+		"assistant": {Hash: sha(assistant), Content: assistant},
+	})
+	if err := cache.SaveInteraction(config.InteractionsDir(), &cache.InteractionRecord{
+		SnapshotID:    "post-1",
+		PreSnapshotID: "pre-1",
+		EndedAt:       "2026-10-01T00:00:00Z",
+		Changes: []*diff.Change{{
+			FilePath: "a.txt", Type: diff.Modified, Hash: sha(assistant), PreviousHash: sha(base), Source: "Edit",
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		// stage prepares the real index and returns the GIT_INDEX_FILE git
+		// would export for the commit, or "" when it commits the real index
+		stage func(t *testing.T) string
+		want  string
+	}{
+		{
+			name: "staged",
+			stage: func(t *testing.T) string {
+				gitIn(t, dir, nil, "add", "a.txt")
+				return ""
+			},
+			want: "CodeTracker-Assistant-Lines: 1/1 (100.0%)",
+		},
+		{
+			// git commit -a: the real index is untouched, a locked copy gets every change
+			name: "commit -a",
+			stage: func(t *testing.T) string {
+				data, err := os.ReadFile(filepath.Join(dir, ".git", "index"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				writeFile(t, filepath.Join(dir, ".git", "index.lock"), string(data))
+				gitIn(t, dir, []string{"GIT_INDEX_FILE=.git/index.lock"}, "add", "-u")
+				return ".git/index.lock"
+			},
+			want: "CodeTracker-Assistant-Lines: 1/2 (50.0%)",
+		},
+		{
+			// git commit a.txt: a temporary index holds HEAD plus the given paths,
+			// while the real index has other staged changes
+			name: "commit paths",
+			stage: func(t *testing.T) string {
+				gitIn(t, dir, nil, "add", "h.txt")
+				next := filepath.Join(dir, ".git", "next-index.lock")
+				gitIn(t, dir, []string{"GIT_INDEX_FILE=" + next}, "read-tree", "HEAD")
+				gitIn(t, dir, []string{"GIT_INDEX_FILE=" + next}, "add", "a.txt")
+				return next
+			},
+			want: "CodeTracker-Assistant-Lines: 1/1 (100.0%)",
+		},
+		{
+			name: "commit other paths",
+			stage: func(t *testing.T) string {
+				gitIn(t, dir, nil, "add", "a.txt")
+				next := filepath.Join(dir, ".git", "next-index.lock")
+				gitIn(t, dir, []string{"GIT_INDEX_FILE=" + next}, "read-tree", "HEAD")
+				gitIn(t, dir, []string{"GIT_INDEX_FILE=" + next}, "add", "h.txt")
+				return next
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(filepath.Join(dir, ".git", "index.lock"))
+			os.Remove(filepath.Join(dir, ".git", "next-index.lock"))
+			gitIn(t, dir, nil, "reset", "-q", "--hard", "HEAD")
+			writeFile(t, filepath.Join(dir, "a.txt"), assistant)
+			writeFile(t, filepath.Join(dir, "h.txt"), human)
+
+			t.Setenv("GIT_INDEX_FILE", tt.stage(t))
+			msgFile := filepath.Join(t.TempDir(), "COMMIT_EDITMSG")
+			writeFile(t, msgFile, "Change things\n")
+
+			if err := prepareCommitMsg([]string{msgFile}); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(msgFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			message := string(data)
+
+			if tt.want == "" {
+				if strings.Contains(message, trailerInteraction) {
+					t.Errorf("unexpected trailers:\n%s", message)
+				}
+				return
+			}
+			if !strings.Contains(message, trailerInteraction+": post-1") || !strings.Contains(message, tt.want) {
+				t.Errorf("message lacks %q:\n%s", tt.want, message)
+			}
+		})
+	}
+}
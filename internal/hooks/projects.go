@@ -0,0 +1,90 @@
+package hooks
+
+import (
+	"sort"
+
+	"codetracker-hooks/internal/api"
+	"codetracker-hooks/internal/config"
+	"codetracker-hooks/internal/diff"
+	"codetracker-hooks/internal/git"
+)
+
+// projectBatch is the part of a change set that belongs to one project
+type projectBatch struct {
+	target  config.ProjectTarget
+	changes []*diff.Change
+}
+
+// primary reports whether the batch belongs to the session's primary project
+func (b *projectBatch) primary(e *env) bool {
+	return b.target.ProjectHash == e.primary.ProjectHash
+}
+
+// originURL returns the origin remote of the repository containing the project root, if any
+func originURL(projectRoot string) string {
+	repo, err := git.Open(projectRoot)
+	if err != nil {
+		return ""
+	}
+	return repo.RemoteURL("origin")
+}
+
+// route splits changes by project. The primary project's batch always comes
+// first, even when empty; other projects are only included when they have changes.
+func (e *env) route(changes []*diff.Change) []*projectBatch {
+	primary := &projectBatch{target: e.primary, changes: []*diff.Change{}}
+	byHash := map[string]*projectBatch{e.primary.ProjectHash: primary}
+
+	for _, change := range changes {
+		target := e.creds.TargetFor(change.FilePath, e.remoteURL)
+		batch, ok := byHash[target.ProjectHash]
+		if !ok {
+			batch = &projectBatch{target: target}
+			byHash[target.ProjectHash] = batch
+		}
+		batch.changes = append(batch.changes, change)
+	}
+
+	others := make([]*projectBatch, 0, len(byHash)-1)
+	for hash, batch := range byHash {
+		if hash != e.primary.ProjectHash {
+			others = append(others, batch)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool {
+		return others[i].target.ProjectHash < others[j].target.ProjectHash
+	})
+
+	return append([]*projectBatch{primary}, others...)
+}
+
+// clientFor returns an API client authenticated for the target project
+func (e *env) clientFor(target config.ProjectTarget) *api.Client {
+	if target.APIKey == "" || target.APIKey == e.primary.APIKey {
+		return e.client
+	}
+	return api.NewClient(e.cfg.ServerURL, target.APIKey)
+}
+
+// recordOthers runs create for each secondary project batch with its parent
+// snapshot ID and returns the updated snapshot IDs of all secondary projects.
+// A failure for one project leaves its previous ID in place.
+func (e *env) recordOthers(batches []*projectBatch, parents map[string]string, create func(client *api.Client, b *projectBatch, parentID string) (api.FlexibleID, error)) map[string]string {
+	ids := make(map[string]string, len(parents))
+	for hash, id := range parents {
+		ids[hash] = id
+	}
+
+	for _, b := range batches[1:] {
+		id, err := create(e.clientFor(b.target), b, ids[b.target.ProjectHash])
+		if err != nil || id == "" {
+			continue
+		}
+		ids[b.target.ProjectHash] = id.String()
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+	return ids
+}
@@ -0,0 +1,67 @@
+package hooks
+
+import (
+	"os"
+
+	"codetracker-hooks/internal/api"
+	"codetracker-hooks/internal/config"
+	"codetracker-hooks/internal/session"
+)
+
+// sessionStart opens a server-side session record
+func sessionStart(input *Input) (*Output, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.SessionTracking.Enabled {
+		return nil, nil
+	}
+
+	e, err := newEnv(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.resolveProject(); err != nil {
+		return nil, err
+	}
+
+	return nil, e.client.StartSession(&api.StartSessionRequest{
+		ProjectHash:     e.primary.ProjectHash,
+		ClaudeSessionID: input.SessionID,
+		Source:          input.Source,
+		StartedAt:       input.timestamp(),
+	})
+}
+
+// sessionEnd cleans up leftover prompt state and closes the server-side session record
+func sessionEnd(input *Input) (*Output, error) {
+	// Drop prompt state left behind if the session ended before the stop hook ran
+	if sessionData, err := session.Load(config.SessionFile()); err == nil && sessionData.ClaudeSessionID == input.SessionID {
+		session.Delete(config.SessionFile())
+		os.Remove(config.CheckpointFile())
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.SessionTracking.Enabled {
+		return nil, nil
+	}
+
+	e, err := newEnv(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.resolveProject(); err != nil {
+		return nil, err
+	}
+
+	return nil, e.client.EndSession(&api.EndSessionRequest{
+		ProjectHash:     e.primary.ProjectHash,
+		ClaudeSessionID: input.SessionID,
+		Reason:          input.Reason,
+		EndedAt:         input.timestamp(),
+	})
+}
@@ -0,0 +1,344 @@
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+
+	"codetracker-hooks/internal/api"
+	"codetracker-hooks/internal/cache"
+	"codetracker-hooks/internal/config"
+	"codetracker-hooks/internal/diff"
+	"codetracker-hooks/internal/policy"
+	"codetracker-hooks/internal/session"
+	"codetracker-hooks/internal/transcript"
+)
+
+// stop records the post-prompt interaction and the conversation since user_prompt_submit
+func stop(input *Input) (*Output, error) {
+	timestamp := input.timestamp()
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// Load session data from pre-prompt hook; without it (the pre-prompt
+	// snapshot wasn't created) only policies are evaluated.
+	// stop_hook_active means a policy already blocked once.
+	sessionData, _ := session.Load(config.SessionFile())
+	enforce := !input.StopHookActive && policy.Applies(cfg.Policies, policy.EventStop)
+	if sessionData == nil && !enforce {
+		os.Remove(config.PolicyBaselineFile())
+		return nil, nil
+	}
+
+	// Scan files and calculate changes
+	projectRoot := config.GetProjectRoot()
+	currentFiles, err := scanProject(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep Claude working if a policy is violated; the interaction is recorded
+	// once a later stop passes
+	if enforce {
+		if blocked := stopPolicies(cfg, currentFiles); blocked != nil {
+			return blocked, nil
+		}
+	}
+	os.Remove(config.PolicyBaselineFile())
+	if sessionData == nil {
+		return nil, nil
+	}
+
+	e, err := newEnv(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load previous snapshot
+	lastSnapshot, _ := cache.LoadLastSnapshot(config.LastSnapshotFile())
+	var prevFiles map[string]*diff.SnapshotFileInfo
+	var prevTranscript *cache.TranscriptState
+	if lastSnapshot != nil {
+		prevFiles = lastSnapshot.Files
+		prevTranscript = lastSnapshot.Transcript
+	}
+
+	changes := diff.CalculateChanges(currentFiles, prevFiles)
+
+	// Check only_on_changes setting
+	if len(changes) == 0 && e.cfg.AutoSnapshot.OnlyOnChanges {
+		// No changes and configured to skip - clean up and exit
+		session.Delete(config.SessionFile())
+		os.Remove(config.CheckpointFile())
+		return nil, nil
+	}
+
+	if err := e.resolveProject(); err != nil {
+		return nil, err
+	}
+
+	// Handle conversation tracking: collect new entries since user_prompt_submit
+	var transcriptState *cache.TranscriptState
+	var apiEntries []api.ConversationEntry
+	var assistant []api.AssistantMessage
+	var usage *api.InteractionUsage
+	var health *api.TranscriptHealth
+	var toolInputs []toolInput
+	// cursors[i] is the transcript position after entries[i]; entryOf[j] is
+	// the index of the entry apiEntries[j] came from
+	var entries []*transcript.Entry
+	var cursors []*cache.TranscriptState
+	var entryOf []int
+
+	if input.TranscriptPath != "" && e.cfg.ConversationTracking.Enabled {
+		// Read all new transcript entries since user_prompt_submit; they are sent in pages below
+		transcriptState = prevTranscript
+		if r, err := transcript.Open(input.TranscriptPath, sessionData.ClaudeSessionID, prevTranscript); err == nil {
+			for r.Next() {
+				entries = append(entries, r.Entry())
+				// Reading on only appends to the slices the copy shares
+				cursor := *r.Cursor()
+				cursors = append(cursors, &cursor)
+			}
+			if r.Err() == nil {
+				transcriptState = r.Cursor()
+			}
+			health = transcriptHealth(r.Health())
+			r.Close()
+		}
+
+		if len(entries) > 0 {
+			// Debug: log all entry types found
+			typeCount := make(map[string]int)
+			for _, entry := range entries {
+				t := entry.Type
+				if t == "" {
+					t = "unknown"
+				}
+				typeCount[t]++
+			}
+			debugData, _ := json.MarshalIndent(map[string]interface{}{
+				"total_entries": len(entries),
+				"types":         typeCount,
+				"first_entry":   entries[0],
+			}, "", "  ")
+			os.WriteFile("/tmp/codetracker-transcript-debug.json", debugData, 0644)
+
+			// Filter and convert to API format
+			toolCalls := make(map[string]*api.ToolCall)
+			for i, entry := range entries {
+				first := len(apiEntries)
+				// Apply filter - only keep user/assistant with text content
+				filtered := filterEntryData(entry)
+				if filtered != nil {
+					conv := conversationEntry(entry, filtered.Role, filtered.Content)
+					conv.Attachments = filtered.Attachments
+					apiEntries = append(apiEntries, conv)
+				}
+
+				toolInputs = append(toolInputs, collectToolInputs(entry, projectRoot)...)
+
+				// Optionally keep tool calls as structured entries
+				if e.cfg.ConversationTracking.IncludeToolUse {
+					toolEntries := extractToolCalls(entry, projectRoot, e.cfg.ConversationTracking.MaxToolOutputBytes, toolCalls)
+					apiEntries = append(apiEntries, toolEntries...)
+				}
+				for range apiEntries[first:] {
+					entryOf = append(entryOf, i)
+				}
+			}
+
+			// Total the token usage of the turn; optionally record it per assistant response too
+			messages := assistantMessages(entries, e.cfg.ConversationTracking.IncludeThinking, e.cfg.ConversationTracking.MaxThinkingBytes)
+			usage = interactionUsage(e.cfg, messages)
+			if e.cfg.ConversationTracking.IncludeAssistantMetadata {
+				assistant = messages
+			}
+		}
+	}
+
+	// Tag changes with the sources the tool and subagent hooks recorded, then
+	// match the rest against the transcript's tool calls, if it was read
+	applySources(changes, checkpointSources())
+	attributeChanges(changes, toolInputs)
+
+	// Upload the images of each page just before sending it. Images of a
+	// failed page keep their IDs with the cursor, so the resend reuses them.
+	uploaded := make(map[string]string)
+	if prevTranscript != nil && prevTranscript.SessionID == sessionData.ClaudeSessionID {
+		for key, id := range prevTranscript.Attachments {
+			uploaded[key] = id
+		}
+	}
+	var uploadPage func(start, end int)
+	if e.cfg.ConversationTracking.UploadImages {
+		uploadPage = func(start, end int) {
+			for j := start; j < end; j++ {
+				if len(apiEntries[j].Attachments) > 0 {
+					uploadImages(e, sessionData.ClaudeSessionID, entries[entryOf[j]], apiEntries[j].Attachments, uploaded)
+				}
+			}
+		}
+	}
+
+	// The conversation belongs to the primary project
+	conversationStartID, conversationEndID, sent := sendConversation(e, sessionData.ClaudeSessionID, apiEntries, health, uploadPage)
+	if transcriptState != nil {
+		transcriptState.Unsent = false
+		transcriptState.Attachments = nil
+	}
+	if sent < len(apiEntries) {
+		// Resume before the first entry not fully stored, so the next stop
+		// sends it and the rest again
+		transcriptState = prevTranscript
+		if i := entryOf[sent]; i > 0 {
+			transcriptState = cursors[i-1]
+		}
+		if transcriptState == nil || transcriptState.SessionID != sessionData.ClaudeSessionID {
+			transcriptState = &cache.TranscriptState{SessionID: sessionData.ClaudeSessionID}
+		}
+		transcriptState.Unsent = true
+		if len(uploaded) > 0 {
+			transcriptState.Attachments = uploaded
+		}
+	}
+
+	// Create interaction on server; changes mapped to other projects go to those projects
+	batches := e.route(changes)
+	req := &api.CreateInteractionRequest{
+		ProjectHash:         e.primary.ProjectHash,
+		Message:             "[AUTO-POST] " + sessionData.Prompt,
+		Changes:             batches[0].changes,
+		ParentSnapshotID:    sessionData.PreSnapshotID,
+		ClaudeSessionID:     sessionData.ClaudeSessionID,
+		StartedAt:           sessionData.StartedAt,
+		EndedAt:             timestamp,
+		ConversationStartID: conversationStartID,
+		ConversationEndID:   conversationEndID,
+		Git:                 gitMetadata(),
+		Labels:              sessionData.Labels,
+		Ticket:              sessionData.Ticket,
+		Usage:               usage,
+		AssistantMessages:   assistant,
+	}
+
+	resp, err := e.client.CreateInteraction(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prepare snapshot ID
+	snapshotID := resp.SnapshotID.String()
+	if snapshotID == "" {
+		snapshotID = sessionData.PreSnapshotID
+	}
+
+	projectIDs := e.recordOthers(batches, sessionData.ProjectPreSnapshotIDs, func(client *api.Client, b *projectBatch, parentID string) (api.FlexibleID, error) {
+		resp, err := client.CreateInteraction(&api.CreateInteractionRequest{
+			ProjectHash:      b.target.ProjectHash,
+			Message:          req.Message,
+			Changes:          b.changes,
+			ParentSnapshotID: parentID,
+			ClaudeSessionID:  sessionData.ClaudeSessionID,
+			StartedAt:        sessionData.StartedAt,
+			EndedAt:          timestamp,
+			Git:              req.Git,
+			Labels:           req.Labels,
+			Ticket:           req.Ticket,
+		})
+		if err != nil {
+			return "", err
+		}
+		return resp.SnapshotID, nil
+	})
+
+	// Record the interaction locally so it can be reverted later
+	cache.SaveBlobs(config.ObjectsDir(), currentFiles)
+	cache.SaveInteraction(config.InteractionsDir(), &cache.InteractionRecord{
+		SnapshotID:      snapshotID,
+		PreSnapshotID:   sessionData.PreSnapshotID,
+		Prompt:          sessionData.Prompt,
+		ClaudeSessionID: sessionData.ClaudeSessionID,
+		StartedAt:       sessionData.StartedAt,
+		EndedAt:         timestamp,
+		Changes:         changes,
+	})
+
+	// Save last snapshot cache with transcript state
+	if err := cache.SaveCachedSnapshot(config.LastSnapshotFile(), currentFiles, &cache.CachedSnapshot{
+		SnapshotID:         snapshotID,
+		Transcript:         transcriptState,
+		ProjectSnapshotIDs: projectIDs,
+	}); err != nil {
+		return nil, err
+	}
+
+	// Clean up session and tool checkpoint files
+	session.Delete(config.SessionFile())
+	os.Remove(config.CheckpointFile())
+
+	// Drop local history beyond the retention limits
+	pruneHistory(e.cfg)
+
+	return nil, nil
+}
+
+// sendConversation uploads entries in pages of max_entries_per_request. The
+// first page carries the transcript health, which is sent alone when there
+// are no entries. Sending stops at the first failed page, so the returned IDs
+// of the first and last stored entries cover a contiguous range; sent counts
+// the entries stored. beforePage, if set, runs on each page's range of
+// entries before it is sent.
+func sendConversation(e *env, sessionID string, entries []api.ConversationEntry, health *api.TranscriptHealth, beforePage func(start, end int)) (startID, endID *int64, sent int) {
+	// With nothing to store, still report the lines read, e.g. a turn whose
+	// lines were all malformed; no entry range is returned
+	if len(entries) == 0 {
+		if health != nil {
+			e.client.SendConversations(&api.SendConversationsRequest{
+				ProjectHash:      e.primary.ProjectHash,
+				SessionID:        sessionID,
+				Entries:          []api.ConversationEntry{},
+				TranscriptHealth: health,
+			})
+		}
+		return nil, nil, 0
+	}
+
+	pageSize := e.cfg.ConversationTracking.MaxEntriesPerRequest
+	if pageSize <= 0 {
+		pageSize = len(entries)
+	}
+
+	for start := 0; start < len(entries); start += pageSize {
+		end := min(start+pageSize, len(entries))
+		if beforePage != nil {
+			beforePage(start, end)
+		}
+		convReq := &api.SendConversationsRequest{
+			ProjectHash: e.primary.ProjectHash,
+			SessionID:   sessionID,
+			Entries:     entries[start:end],
+		}
+		if start == 0 {
+			convReq.TranscriptHealth = health
+		}
+
+		// Debug: log what we're sending
+		reqDebug, _ := json.MarshalIndent(convReq, "", "  ")
+		os.WriteFile("/tmp/codetracker-conv-request.json", reqDebug, 0644)
+
+		convResp, err := e.client.SendConversations(convReq)
+		if err != nil || convResp == nil {
+			break
+		}
+		if startID == nil {
+			startID = &convResp.StartID
+		}
+		endID = &convResp.EndID
+		sent = end
+	}
+
+	return startID, endID, sent
+}
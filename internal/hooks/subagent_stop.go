@@ -0,0 +1,127 @@
+package hooks
+
+import (
+	"codetracker-hooks/internal/api"
+	"codetracker-hooks/internal/cache"
+	"codetracker-hooks/internal/config"
+	"codetracker-hooks/internal/diff"
+	"codetracker-hooks/internal/session"
+	"codetracker-hooks/internal/transcript"
+)
+
+// subagentStop records a subagent's changes as a child interaction of the current prompt
+func subagentStop(input *Input) (*Output, error) {
+	// Subagents are recorded as children of the current prompt
+	sessionData, err := session.Load(config.SessionFile())
+	if err != nil || sessionData == nil {
+		return nil, nil
+	}
+
+	e, err := loadEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	currentFiles, err := scanProject(e.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	prevFiles, parentSnapshotID, prevProjectIDs := loadCheckpoint(sessionData)
+	changes := diff.CalculateChanges(currentFiles, prevFiles)
+	if len(changes) == 0 && e.cfg.AutoSnapshot.OnlyOnChanges {
+		return nil, nil
+	}
+	if err := e.resolveProject(); err != nil {
+		return nil, err
+	}
+	// Only changes matching the subagent's own tool calls are its work; the
+	// rest may be the parent's or the developer's and is attributed at stop
+	inputs := subagentToolInputs(input, sessionData)
+	for _, change := range changes {
+		if _, ids := matchToolCall(change.FilePath, inputs); len(ids) > 0 {
+			change.Source = diff.SourceSubagent
+			change.ToolUseIDs = ids
+		}
+	}
+
+	// Create child interaction on server; changes mapped to other projects go to those projects
+	batches := e.route(changes)
+	meta := sessionData.Git
+	newRequest := func(b *projectBatch, parentID string) *api.CreateInteractionRequest {
+		return &api.CreateInteractionRequest{
+			ProjectHash:         b.target.ProjectHash,
+			Message:             "[AUTO-SUBAGENT] " + sessionData.Prompt,
+			Changes:             b.changes,
+			ParentSnapshotID:    parentID,
+			ClaudeSessionID:     sessionData.ClaudeSessionID,
+			StartedAt:           sessionData.StartedAt,
+			EndedAt:             input.timestamp(),
+			ParentInteractionID: sessionData.PreSnapshotID,
+			AgentID:             input.AgentID,
+			Git:                 meta,
+			Labels:              sessionData.Labels,
+			Ticket:              sessionData.Ticket,
+		}
+	}
+
+	resp, err := e.client.CreateInteraction(newRequest(batches[0], parentSnapshotID))
+	if err != nil {
+		return nil, err
+	}
+
+	projectIDs := e.recordOthers(batches, prevProjectIDs, func(client *api.Client, b *projectBatch, parentID string) (api.FlexibleID, error) {
+		req := newRequest(b, parentID)
+		req.ParentInteractionID = sessionData.ProjectPreSnapshotIDs[b.target.ProjectHash]
+		resp, err := client.CreateInteraction(req)
+		if err != nil {
+			return "", err
+		}
+		return resp.SnapshotID, nil
+	})
+
+	// Later tool and subagent records build on this one
+	snapshotID := resp.SnapshotID.String()
+	if snapshotID == "" {
+		snapshotID = parentSnapshotID
+	}
+	cache.SaveBlobs(config.ObjectsDir(), currentFiles)
+	return nil, cache.SaveCachedSnapshot(config.CheckpointFile(), currentFiles, &cache.CachedSnapshot{
+		SnapshotID:         snapshotID,
+		ProjectSnapshotIDs: projectIDs,
+		Sources:            recordSources(checkpointSources(), changes),
+	})
+}
+
+// subagentToolInputs returns the tool calls the subagent made, read from its
+// own transcript. Without one, the sidechain entries of the session
+// transcript since the prompt started are used.
+func subagentToolInputs(input *Input, sessionData *session.SessionData) []toolInput {
+	path := input.AgentTranscriptPath
+	sidechainOnly := path == ""
+	var prev *cache.TranscriptState
+	if sidechainOnly {
+		path = input.TranscriptPath
+		if lastSnapshot, _ := cache.LoadLastSnapshot(config.LastSnapshotFile()); lastSnapshot != nil {
+			prev = lastSnapshot.Transcript
+		}
+	}
+	if path == "" {
+		return nil
+	}
+
+	r, err := transcript.Open(path, sessionData.ClaudeSessionID, prev)
+	if err != nil {
+		return nil
+	}
+	defer r.Close()
+
+	var inputs []toolInput
+	projectRoot := config.GetProjectRoot()
+	for r.Next() {
+		if entry := r.Entry(); !sidechainOnly || entry.IsSidechain {
+			inputs = append(inputs, collectToolInputs(entry, projectRoot)...)
+		}
+	}
+	return inputs
+}
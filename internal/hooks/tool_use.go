@@ -0,0 +1,102 @@
+package hooks
+
+import (
+	"codetracker-hooks/internal/api"
+	"codetracker-hooks/internal/cache"
+	"codetracker-hooks/internal/config"
+	"codetracker-hooks/internal/diff"
+	"codetracker-hooks/internal/session"
+)
+
+// preToolUse snapshots changes made outside of tools before a tracked tool runs
+func preToolUse(input *Input) (*Output, error) {
+	return nil, toolSnapshot(input, "[AUTO-PRE-TOOL] ", diff.SourceHuman)
+}
+
+// postToolUse snapshots the changes made by a tracked tool
+func postToolUse(input *Input) (*Output, error) {
+	return nil, toolSnapshot(input, "[AUTO-TOOL] ", input.ToolName)
+}
+
+// toolSnapshot creates a snapshot of changes since the last checkpoint,
+// tagging them with source, and advances the checkpoint. Credentials are only
+// loaded once there is something to send.
+func toolSnapshot(input *Input, messagePrefix, source string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if !cfg.ToolTracking.Tracks(input.ToolName) {
+		return nil
+	}
+
+	// Only record tool snapshots inside a tracked prompt
+	sessionData, err := session.Load(config.SessionFile())
+	if err != nil || sessionData == nil {
+		return nil
+	}
+
+	currentFiles, err := scanProject(cfg)
+	if err != nil {
+		return err
+	}
+
+	prevFiles, parentSnapshotID, prevProjectIDs := loadCheckpoint(sessionData)
+	changes := diff.CalculateChanges(currentFiles, prevFiles)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	e, err := newEnv(cfg)
+	if err != nil {
+		return err
+	}
+	if err := e.resolveProject(); err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		change.Source = source
+		if source == input.ToolName && input.ToolUseID != "" {
+			change.ToolUseIDs = []string{input.ToolUseID}
+		}
+	}
+
+	batches := e.route(changes)
+	meta := sessionData.Git
+	newRequest := func(b *projectBatch, parentID string) *api.CreateSnapshotRequest {
+		return &api.CreateSnapshotRequest{
+			ProjectHash:      b.target.ProjectHash,
+			Message:          messagePrefix + input.ToolName,
+			Changes:          b.changes,
+			ClaudeSessionID:  input.SessionID,
+			ParentSnapshotID: parentID,
+			ToolName:         input.ToolName,
+			ToolUseID:        input.ToolUseID,
+			Git:              meta,
+			Labels:           sessionData.Labels,
+			Ticket:           sessionData.Ticket,
+		}
+	}
+
+	resp, err := e.client.CreateSnapshot(newRequest(batches[0], parentSnapshotID))
+	if err != nil {
+		return err
+	}
+
+	projectIDs := e.recordOthers(batches, prevProjectIDs, func(client *api.Client, b *projectBatch, parentID string) (api.FlexibleID, error) {
+		resp, err := client.CreateSnapshot(newRequest(b, parentID))
+		if err != nil {
+			return "", err
+		}
+		return resp.SnapshotID, nil
+	})
+
+	cache.SaveBlobs(config.ObjectsDir(), currentFiles)
+	return cache.SaveCachedSnapshot(config.CheckpointFile(), currentFiles, &cache.CachedSnapshot{
+		SnapshotID:         resp.SnapshotID.String(),
+		ProjectSnapshotIDs: projectIDs,
+		Sources:            recordSources(checkpointSources(), changes),
+	})
+}
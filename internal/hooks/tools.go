@@ -0,0 +1,161 @@
+package hooks
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"codetracker-hooks/internal/api"
+	"codetracker-hooks/internal/transcript"
+)
+
+// maxInputSummary is the maximum length of a tool input summary
+const maxInputSummary = 200
+
+// exitCodePattern matches the exit code line Claude Code prepends to failed Bash results
+var exitCodePattern = regexp.MustCompile(`(?m)^Exit code (\d+)`)
+
+// extractToolCalls builds tool_use entries from assistant tool_use blocks and
+// completes previously seen calls from user tool_result blocks.
+// calls maps tool_use IDs to calls seen so far in this batch.
+func extractToolCalls(entry *transcript.Entry, projectRoot string, maxOutput int, calls map[string]*api.ToolCall) []api.ConversationEntry {
+	var entries []api.ConversationEntry
+	for i := range entry.Blocks() {
+		block := &entry.Message.Content.Blocks[i]
+
+		switch {
+		case entry.Type == "assistant" && block.Type == "tool_use":
+			call := newToolCall(block, projectRoot)
+			if call.ToolUseID != "" {
+				calls[call.ToolUseID] = call
+			}
+			conv := conversationEntry(entry, "tool_use", call.Name+": "+call.InputSummary)
+			conv.Tool = call
+			entries = append(entries, conv)
+
+		case entry.Type == "user" && block.Type == "tool_result":
+			if call, ok := calls[block.ToolUseID]; ok {
+				applyToolResult(call, block, maxOutput)
+			}
+		}
+	}
+
+	return entries
+}
+
+// newToolCall creates a pending tool call from a tool_use block
+func newToolCall(block *transcript.Block, projectRoot string) *api.ToolCall {
+	call := &api.ToolCall{
+		ToolUseID: block.ID,
+		Name:      block.Name,
+		Status:    api.ToolStatusPending,
+	}
+	call.InputSummary = summarizeToolInput(call.Name, block.Input)
+
+	for _, key := range []string{"file_path", "notebook_path"} {
+		if path, ok := block.Input[key].(string); ok && path != "" {
+			call.FilePaths = append(call.FilePaths, relativeToRoot(projectRoot, path))
+		}
+	}
+
+	return call
+}
+
+// summarizeToolInput returns a short human-readable description of a tool input
+func summarizeToolInput(name string, input map[string]interface{}) string {
+	var keys []string
+	switch name {
+	case "Bash":
+		keys = []string{"command"}
+	case "Edit", "MultiEdit", "Write", "Read":
+		keys = []string{"file_path"}
+	case "NotebookEdit":
+		keys = []string{"notebook_path"}
+	case "Grep", "Glob":
+		keys = []string{"pattern"}
+	case "WebFetch":
+		keys = []string{"url"}
+	case "WebSearch":
+		keys = []string{"query"}
+	case "Task":
+		keys = []string{"description"}
+	}
+
+	for _, key := range keys {
+		if val, ok := input[key].(string); ok && val != "" {
+			summary, _ := truncate(val, maxInputSummary)
+			return summary
+		}
+	}
+
+	// Fall back to compact JSON of the whole input
+	data, err := json.Marshal(input)
+	if err != nil {
+		return ""
+	}
+	summary, _ := truncate(string(data), maxInputSummary)
+	return summary
+}
+
+// applyToolResult fills in the status and output of a tool call
+func applyToolResult(call *api.ToolCall, block *transcript.Block, maxOutput int) {
+	output := toolResultText(block.Content)
+
+	call.Status = api.ToolStatusOK
+	if block.IsError {
+		call.Status = api.ToolStatusError
+	}
+
+	if m := exitCodePattern.FindStringSubmatch(output); m != nil {
+		if code, err := strconv.Atoi(m[1]); err == nil {
+			call.ExitCode = &code
+		}
+	} else if call.Name == "Bash" && call.Status == api.ToolStatusOK {
+		code := 0
+		call.ExitCode = &code
+	}
+
+	call.Output, call.OutputTruncated = truncate(output, maxOutput)
+}
+
+// toolResultText extracts text from tool_result content (string or content blocks)
+func toolResultText(content transcript.Content) string {
+	if content.Blocks == nil {
+		return content.Text
+	}
+
+	var texts []string
+	for _, block := range content.Blocks {
+		if block.Type == "text" {
+			texts = append(texts, block.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// relativeToRoot converts an absolute path inside the project to a slash-separated relative path
+func relativeToRoot(projectRoot, path string) string {
+	if !filepath.IsAbs(path) {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(projectRoot, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// truncate shortens s to at most maxBytes without splitting a UTF-8 character
+func truncate(s string, maxBytes int) (string, bool) {
+	if maxBytes <= 0 || len(s) <= maxBytes {
+		return s, false
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut], true
+}
@@ -0,0 +1,138 @@
+package hooks
+
+import (
+	"fmt"
+	"strings"
+
+	"codetracker-hooks/internal/api"
+	"codetracker-hooks/internal/transcript"
+)
+
+// conversationEntry creates an API conversation entry carrying the transcript entry's identity
+func conversationEntry(entry *transcript.Entry, entryType, data string) api.ConversationEntry {
+	conv := api.ConversationEntry{
+		EntryType:  entryType,
+		EntryData:  data,
+		UUID:       entry.UUID,
+		ParentUUID: entry.ParentUUID,
+		Timestamp:  entry.Timestamp,
+	}
+	if entry.Message != nil {
+		conv.Model = entry.Message.Model
+	}
+	return conv
+}
+
+// transcriptHealth converts the health of a transcript read for the API
+func transcriptHealth(h transcript.Health) *api.TranscriptHealth {
+	if h.Lines == 0 {
+		return nil
+	}
+	health := &api.TranscriptHealth{LinesRead: h.Lines, Malformed: h.Malformed}
+	for _, pe := range h.Errors {
+		health.Errors = append(health.Errors, api.TranscriptParseError{Offset: pe.Offset, Error: pe.Err})
+	}
+	return health
+}
+
+// FilteredEntry represents a filtered conversation entry
+type FilteredEntry struct {
+	Role        string           `json:"role"`
+	Content     string           `json:"content"`
+	Attachments []api.Attachment `json:"attachments,omitempty"`
+}
+
+// filterEntryData extracts core text from a transcript entry
+// Returns filtered entry or nil if no text content
+func filterEntryData(entry *transcript.Entry) *FilteredEntry {
+	// Only process user and assistant types
+	if entry.Type != "user" && entry.Type != "assistant" {
+		return nil
+	}
+	if entry.Message == nil {
+		return nil
+	}
+	content := entry.Message.Content
+
+	var text string
+	var attachments []api.Attachment
+
+	if entry.Type == "user" {
+		if content.Blocks != nil {
+			text, attachments = userContent(content.Blocks)
+		} else {
+			// Handle case where content is a plain string
+			text = content.Text
+		}
+	} else if entry.Type == "assistant" {
+		// For assistant: extract text from type='text' items
+		var texts []string
+		for _, block := range content.Blocks {
+			if block.Type == "text" {
+				texts = append(texts, block.Text)
+			}
+		}
+		text = strings.Join(texts, "\n")
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+
+	return &FilteredEntry{
+		Role:        entry.Type,
+		Content:     text,
+		Attachments: attachments,
+	}
+}
+
+// userContent joins the text of user content blocks, replacing images and
+// documents with placeholders. Bare strings from older transcripts are
+// concatenated as they are; other blocks go on separate lines.
+func userContent(blocks []transcript.Block) (string, []api.Attachment) {
+	var parts []string
+	var attachments []api.Attachment
+	bare := false
+
+	for _, block := range blocks {
+		switch block.Type {
+		case "":
+			if bare {
+				parts[len(parts)-1] += block.Text
+			} else {
+				parts = append(parts, block.Text)
+			}
+			bare = true
+			continue
+		case "text":
+			parts = append(parts, block.Text)
+		case "image", "document":
+			a := api.Attachment{Type: block.Type}
+			if block.Source != nil {
+				a.MediaType = block.Source.MediaType
+				a.Size = block.Source.Size()
+			}
+			attachments = append(attachments, a)
+			parts = append(parts, placeholder(a))
+		}
+		bare = false
+	}
+
+	return strings.Join(parts, "\n"), attachments
+}
+
+// placeholder describes an attachment in conversation text, e.g. "[image: image/png, 2048 bytes]"
+func placeholder(a api.Attachment) string {
+	var details []string
+	if a.MediaType != "" {
+		details = append(details, a.MediaType)
+	}
+	if a.Size > 0 {
+		details = append(details, fmt.Sprintf("%d bytes", a.Size))
+	}
+	if len(details) == 0 {
+		return "[" + a.Type + "]"
+	}
+	return "[" + a.Type + ": " + strings.Join(details, ", ") + "]"
+}
@@ -0,0 +1,187 @@
+package hooks
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"codetracker-hooks/internal/api"
+	"codetracker-hooks/internal/cache"
+	"codetracker-hooks/internal/config"
+	"codetracker-hooks/internal/diff"
+	"codetracker-hooks/internal/policy"
+	"codetracker-hooks/internal/scanner"
+	"codetracker-hooks/internal/session"
+	"codetracker-hooks/internal/tagging"
+	"codetracker-hooks/internal/transcript"
+)
+
+// userPromptSubmit creates the pre-prompt snapshot and saves session data for stop
+func userPromptSubmit(input *Input) (*Output, error) {
+	// Skip empty prompts
+	if strings.TrimSpace(input.Prompt) == "" {
+		return nil, nil
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// Block the prompt if a policy is violated; nothing is recorded. Policies
+	// are enforced whether or not the prompt is tracked. Only the prompt is
+	// checked: the changes here are the developer's own edits.
+	if blocked := checkPolicies(cfg, EventUserPromptSubmit, input.Prompt, nil); blocked != nil {
+		return blocked, nil
+	}
+
+	// Keep the project state for Stop policies, independent of the server
+	var currentFiles map[string]*scanner.FileInfo
+	if policy.Applies(cfg.Policies, policy.EventStop) {
+		if currentFiles, err = scanProject(cfg); err != nil {
+			return nil, err
+		}
+		if err := cache.SaveCachedSnapshot(config.PolicyBaselineFile(), currentFiles, &cache.CachedSnapshot{}); err != nil {
+			return nil, err
+		}
+	}
+
+	// Check if auto-snapshot is enabled
+	if !cfg.AutoSnapshot.Enabled {
+		return nil, nil
+	}
+
+	// Check skip patterns
+	for _, pattern := range cfg.AutoSnapshot.SkipPatterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			continue
+		}
+		if re.MatchString(input.Prompt) {
+			return nil, nil
+		}
+	}
+
+	e, err := newEnv(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Scan files and calculate changes
+	if currentFiles == nil {
+		if currentFiles, err = scanProject(e.cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	// Load previous snapshot
+	lastSnapshot, _ := cache.LoadLastSnapshot(config.LastSnapshotFile())
+	var prevFiles map[string]*diff.SnapshotFileInfo
+	if lastSnapshot != nil {
+		prevFiles = lastSnapshot.Files
+	}
+
+	changes := diff.CalculateChanges(currentFiles, prevFiles)
+
+	// Optionally tell Claude about the developer's edits since the last snapshot
+	var output *Output
+	if e.cfg.ContextInjection.Enabled && lastSnapshot != nil {
+		output = humanChangesContext(changes, currentFiles, e.cfg.ContextInjection.MaxFiles)
+	}
+
+	// Move the transcript cursor to the end so stop only reads this prompt's
+	// entries, unless the last stop left entries unsent in this session
+	var transcriptState *cache.TranscriptState
+	if input.TranscriptPath != "" && e.cfg.ConversationTracking.Enabled {
+		var prevTranscript *cache.TranscriptState
+		if lastSnapshot != nil {
+			prevTranscript = lastSnapshot.Transcript
+		}
+		if prevTranscript != nil && prevTranscript.Unsent && prevTranscript.SessionID == input.SessionID {
+			transcriptState = prevTranscript
+		} else if r, err := transcript.Open(input.TranscriptPath, input.SessionID, prevTranscript); err == nil {
+			for r.Next() {
+			}
+			transcriptState = r.Cursor()
+			r.Close()
+		}
+	}
+
+	// Label the prompt by intent and ticket
+	tags := tagging.Classify(e.cfg.Tagging, input.Prompt)
+
+	if err := e.resolveProject(); err != nil {
+		return nil, err
+	}
+
+	// Create snapshot on server; changes mapped to other projects go to those projects
+	batches := e.route(changes)
+	req := &api.CreateSnapshotRequest{
+		ProjectHash:     e.primary.ProjectHash,
+		Message:         "[AUTO-PRE] " + input.Prompt,
+		Changes:         batches[0].changes,
+		ClaudeSessionID: input.SessionID,
+		Git:             gitMetadata(),
+		Labels:          tags.Labels,
+		Ticket:          tags.Ticket,
+	}
+
+	var prevProjectIDs map[string]string
+	if lastSnapshot != nil {
+		req.ParentSnapshotID = lastSnapshot.SnapshotID
+		prevProjectIDs = lastSnapshot.ProjectSnapshotIDs
+	}
+
+	resp, err := e.client.CreateSnapshot(req)
+	if err != nil {
+		os.WriteFile("/tmp/codetracker-error.log", []byte(err.Error()), 0644)
+		return output, err
+	}
+
+	projectIDs := e.recordOthers(batches, prevProjectIDs, func(client *api.Client, b *projectBatch, parentID string) (api.FlexibleID, error) {
+		resp, err := client.CreateSnapshot(&api.CreateSnapshotRequest{
+			ProjectHash:      b.target.ProjectHash,
+			Message:          req.Message,
+			Changes:          b.changes,
+			ClaudeSessionID:  input.SessionID,
+			ParentSnapshotID: parentID,
+			Git:              req.Git,
+			Labels:           req.Labels,
+			Ticket:           req.Ticket,
+		})
+		if err != nil {
+			return "", err
+		}
+		return resp.SnapshotID, nil
+	})
+
+	// Keep file contents so interactions can be reverted later
+	cache.SaveBlobs(config.ObjectsDir(), currentFiles)
+
+	// Save last snapshot cache with updated transcript state
+	if err := cache.SaveCachedSnapshot(config.LastSnapshotFile(), currentFiles, &cache.CachedSnapshot{
+		SnapshotID:         resp.SnapshotID.String(),
+		Transcript:         transcriptState,
+		ProjectSnapshotIDs: projectIDs,
+	}); err != nil {
+		return output, err
+	}
+
+	// Tool checkpoints from the previous prompt no longer apply
+	os.Remove(config.CheckpointFile())
+
+	// Save session data for stop hook
+	sessionData := &session.SessionData{
+		PreSnapshotID:   resp.SnapshotID.String(),
+		Prompt:          input.Prompt,
+		ClaudeSessionID: input.SessionID,
+		StartedAt:       input.timestamp(),
+		Labels:          tags.Labels,
+		Ticket:          tags.Ticket,
+		Git:             req.Git,
+
+		ProjectPreSnapshotIDs: projectIDs,
+	}
+
+	return output, session.Save(config.SessionFile(), sessionData)
+}
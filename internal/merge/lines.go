@@ -0,0 +1,146 @@
+package merge
+
+import "strings"
+
+// SplitLines splits text into lines, keeping line terminators so that
+// joining the result reproduces the original text exactly
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Match pairs a line index in a with an equal line index in b
+type Match struct {
+	A int
+	B int
+}
+
+// maxDiffLines bounds the lines left after stripping a common prefix and
+// suffix. Larger differences are treated as a whole replacement instead of
+// being diffed line by line.
+const maxDiffLines = 20000
+
+// Matches returns the longest common subsequence of a and b as index pairs
+// in increasing order, using the linear-space variant of Myers' O(ND)
+// algorithm
+func Matches(a, b []string) []Match {
+	var matches []Match
+	lcs(a, b, 0, 0, true, &matches)
+	return matches
+}
+
+// lcs appends the matches between a and b, offset by aOff and bOff, to out.
+// At the top level the middle section is only diffed when it is small enough.
+func lcs(a, b []string, aOff, bOff int, top bool, out *[]Match) {
+	// Strip common prefix and suffix; they are matched trivially
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	for i := 0; i < prefix; i++ {
+		*out = append(*out, Match{A: aOff + i, B: bOff + i})
+	}
+
+	midA := a[prefix : len(a)-suffix]
+	midB := b[prefix : len(b)-suffix]
+	if len(midA) > 0 && len(midB) > 0 && (!top || len(midA)+len(midB) <= maxDiffLines) {
+		x, y, u, v := middleSnake(midA, midB)
+		lcs(midA[:x], midB[:y], aOff+prefix, bOff+prefix, false, out)
+		for i := 0; i < u-x; i++ {
+			*out = append(*out, Match{A: aOff + prefix + x + i, B: bOff + prefix + y + i})
+		}
+		lcs(midA[u:], midB[v:], aOff+prefix+u, bOff+prefix+v, false, out)
+	}
+
+	for i := suffix; i > 0; i-- {
+		*out = append(*out, Match{A: aOff + len(a) - i, B: bOff + len(b) - i})
+	}
+}
+
+// middleSnake finds the middle snake of an optimal edit script between a and
+// b by running Myers' greedy search forward from the start and backward from
+// the end until they overlap. It returns the snake from (x, y) to (u, v).
+// a and b must be non-empty and differ in their first and last lines.
+func middleSnake(a, b []string) (x, y, u, v int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	max := (n + m + 1) / 2
+	offset := max + 1
+	// vf holds the furthest x reached on each forward diagonal; vb the
+	// furthest distance from the end reached on each backward diagonal
+	vf := make([]int, 2*max+3)
+	vb := make([]int, 2*max+3)
+
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			var px int
+			if k == -d || (k != d && vf[offset+k-1] < vf[offset+k+1]) {
+				px = vf[offset+k+1]
+			} else {
+				px = vf[offset+k-1] + 1
+			}
+			py := px - k
+			ex, ey := px, py
+			for ex < n && ey < m && a[ex] == b[ey] {
+				ex++
+				ey++
+			}
+			vf[offset+k] = ex
+			if odd && k >= delta-(d-1) && k <= delta+(d-1) && ex+vb[offset+delta-k] >= n {
+				return px, py, ex, ey
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var px int
+			if k == -d || (k != d && vb[offset+k-1] < vb[offset+k+1]) {
+				px = vb[offset+k+1]
+			} else {
+				px = vb[offset+k-1] + 1
+			}
+			py := px - k
+			ex, ey := px, py
+			for ex < n && ey < m && a[n-ex-1] == b[m-ey-1] {
+				ex++
+				ey++
+			}
+			vb[offset+k] = ex
+			if !odd && delta-k >= -d && delta-k <= d && ex+vf[offset+delta-k] >= n {
+				return n - ex, m - ey, n - px, m - py
+			}
+		}
+	}
+
+	// Unreachable: the searches always meet by d = max
+	return 0, 0, 0, 0
+}
+
+// AddedLines returns the lines of b that are not part of the common
+// subsequence with a
+func AddedLines(a, b []string) []string {
+	matched := make(map[int]bool)
+	for _, m := range Matches(a, b) {
+		matched[m.B] = true
+	}
+
+	var added []string
+	for i, line := range b {
+		if !matched[i] {
+			added = append(added, line)
+		}
+	}
+	return added
+}
@@ -0,0 +1,144 @@
+package merge
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// lcsLength computes the longest common subsequence length by dynamic programming
+func lcsLength(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] > cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// rebuild reconstructs b from a and the matches, taking unmatched lines from b
+func rebuild(t *testing.T, a, b []string, matches []Match) string {
+	t.Helper()
+	var sb strings.Builder
+	ia, ib := 0, 0
+	for _, m := range matches {
+		if m.A < ia || m.B < ib {
+			t.Fatalf("matches not increasing at %+v", m)
+		}
+		if a[m.A] != b[m.B] {
+			t.Fatalf("match %+v pairs %q with %q", m, a[m.A], b[m.B])
+		}
+		writeLines(&sb, b[ib:m.B])
+		sb.WriteString(a[m.A])
+		ia, ib = m.A+1, m.B+1
+	}
+	writeLines(&sb, b[ib:])
+	return sb.String()
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want int
+	}{
+		{"empty", "", "", 0},
+		{"insert into empty", "", "a\nb\n", 0},
+		{"delete all", "a\nb\n", "", 0},
+		{"identical", "a\nb\nc\n", "a\nb\nc\n", 3},
+		{"replace middle", "a\nb\nc\n", "a\nx\nc\n", 2},
+		{"insert", "a\nc\n", "a\nb\nc\n", 2},
+		{"delete", "a\nb\nc\n", "a\nc\n", 2},
+		{"swap", "a\nb\n", "b\na\n", 1},
+		{"unterminated last line", "a\nb", "a\nb\n", 1},
+		{"repeated lines", "a\nb\na\nb\na\n", "b\na\nb\na\nb\n", 4},
+		{"disjoint", "a\nb\nc\n", "x\ny\n", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := SplitLines(tt.a), SplitLines(tt.b)
+			matches := Matches(a, b)
+			if len(matches) != tt.want {
+				t.Errorf("got %d matches, want %d", len(matches), tt.want)
+			}
+			if got := rebuild(t, a, b, matches); got != tt.b {
+				t.Errorf("round trip = %q, want %q", got, tt.b)
+			}
+		})
+	}
+}
+
+func TestMatchesRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	alphabet := []string{"a\n", "b\n", "c\n", "d\n"}
+	random := func() []string {
+		lines := make([]string, rng.Intn(30))
+		for i := range lines {
+			lines[i] = alphabet[rng.Intn(len(alphabet))]
+		}
+		return lines
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := random(), random()
+		matches := Matches(a, b)
+		if want := lcsLength(a, b); len(matches) != want {
+			t.Fatalf("Matches(%q, %q) found %d, want %d", a, b, len(matches), want)
+		}
+		if got := rebuild(t, a, b, matches); got != strings.Join(b, "") {
+			t.Fatalf("round trip of %q, %q = %q", a, b, got)
+		}
+	}
+}
+
+func TestMatchesLargeRewrite(t *testing.T) {
+	a := make([]string, 5000)
+	b := make([]string, 5000)
+	for i := range a {
+		a[i] = fmt.Sprintf("old %d\n", i)
+		b[i] = fmt.Sprintf("new %d\n", i)
+	}
+	b[2500] = a[100]
+
+	if got := len(Matches(a, b)); got != 1 {
+		t.Errorf("got %d matches, want 1", got)
+	}
+}
+
+func TestMatchesCutoff(t *testing.T) {
+	a := []string{"head\n"}
+	b := []string{"head\n"}
+	for i := 0; i < maxDiffLines; i++ {
+		a = append(a, fmt.Sprintf("a %d\n", i))
+		b = append(b, fmt.Sprintf("b %d\n", i))
+	}
+	a = append(a, "same\n", "tail\n")
+	b = append(b, "same\n", "tail\n")
+	b[10] = a[10]
+
+	// Only the common prefix and suffix match; the middle is replaced whole
+	want := []Match{{0, 0}, {len(a) - 2, len(b) - 2}, {len(a) - 1, len(b) - 1}}
+	got := Matches(a, b)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestAddedLines(t *testing.T) {
+	got := AddedLines(SplitLines("a\nb\nc\n"), SplitLines("a\nx\nc\ny\n"))
+	if want := []string{"x\n", "y\n"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,132 @@
+package merge
+
+import "strings"
+
+// Conflict markers written into merged output
+const (
+	markerOurs   = "<<<<<<< current\n"
+	markerBase   = "||||||| base\n"
+	markerSep    = "=======\n"
+	markerTheirs = ">>>>>>> revert\n"
+)
+
+// Result holds the outcome of a three-way merge
+type Result struct {
+	Text      string
+	Conflicts int
+}
+
+// Merge3 merges the changes from base to theirs into ours.
+// Overlapping, differing changes are emitted with conflict markers.
+func Merge3(base, ours, theirs string) *Result {
+	o := SplitLines(base)
+	a := SplitLines(ours)
+	b := SplitLines(theirs)
+
+	matchA := make(map[int]int)
+	for _, m := range Matches(o, a) {
+		matchA[m.A] = m.B
+	}
+	matchB := make(map[int]int)
+	for _, m := range Matches(o, b) {
+		matchB[m.A] = m.B
+	}
+
+	var out strings.Builder
+	result := &Result{}
+	io, ia, ib := 0, 0, 0
+
+	for {
+		// Emit lines where all three versions agree
+		for io < len(o) {
+			ja, okA := matchA[io]
+			jb, okB := matchB[io]
+			if !okA || !okB || ja != ia || jb != ib {
+				break
+			}
+			out.WriteString(o[io])
+			io++
+			ia++
+			ib++
+		}
+
+		// Find the next line that is stable in all three versions
+		next := -1
+		for k := io; k < len(o); k++ {
+			ja, okA := matchA[k]
+			jb, okB := matchB[k]
+			if okA && okB && ja >= ia && jb >= ib {
+				next = k
+				break
+			}
+		}
+
+		endO, endA, endB := len(o), len(a), len(b)
+		if next >= 0 {
+			endO, endA, endB = next, matchA[next], matchB[next]
+		}
+
+		if io == endO && ia == endA && ib == endB {
+			break
+		}
+
+		chunkO := o[io:endO]
+		chunkA := a[ia:endA]
+		chunkB := b[ib:endB]
+
+		switch {
+		case equalLines(chunkA, chunkO):
+			writeLines(&out, chunkB)
+		case equalLines(chunkB, chunkO), equalLines(chunkA, chunkB):
+			writeLines(&out, chunkA)
+		default:
+			result.Conflicts++
+			out.WriteString(markerOurs)
+			writeLines(&out, terminated(chunkA))
+			out.WriteString(markerBase)
+			writeLines(&out, terminated(chunkO))
+			out.WriteString(markerSep)
+			writeLines(&out, terminated(chunkB))
+			out.WriteString(markerTheirs)
+		}
+
+		io, ia, ib = endO, endA, endB
+		if next < 0 {
+			break
+		}
+	}
+
+	result.Text = out.String()
+	return result
+}
+
+// equalLines reports whether two line slices are identical
+func equalLines(x, y []string) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// writeLines appends lines to the builder
+func writeLines(sb *strings.Builder, lines []string) {
+	for _, line := range lines {
+		sb.WriteString(line)
+	}
+}
+
+// terminated ensures the last line ends with a newline so markers stay on their own line
+func terminated(lines []string) []string {
+	if len(lines) == 0 || strings.HasSuffix(lines[len(lines)-1], "\n") {
+		return lines
+	}
+	out := make([]string, len(lines))
+	copy(out, lines)
+	out[len(out)-1] += "\n"
+	return out
+}
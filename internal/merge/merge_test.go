@@ -0,0 +1,73 @@
+package merge
+
+import "testing"
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name               string
+		base, ours, theirs string
+		want               string
+		conflicts          int
+	}{
+		{
+			name: "unchanged",
+			base: "a\nb\n", ours: "a\nb\n", theirs: "a\nb\n",
+			want: "a\nb\n",
+		},
+		{
+			name: "only theirs changed",
+			base: "a\nb\nc\n", ours: "a\nb\nc\n", theirs: "a\nB\nc\n",
+			want: "a\nB\nc\n",
+		},
+		{
+			name: "only ours changed",
+			base: "a\nb\nc\n", ours: "a\nB\nc\n", theirs: "a\nb\nc\n",
+			want: "a\nB\nc\n",
+		},
+		{
+			name: "separate changes",
+			base: "a\nb\nc\nd\ne\n", ours: "A\nb\nc\nd\ne\n", theirs: "a\nb\nc\nd\nE\n",
+			want: "A\nb\nc\nd\nE\n",
+		},
+		{
+			name: "same change on both sides",
+			base: "a\nb\nc\n", ours: "a\nX\nc\n", theirs: "a\nX\nc\n",
+			want: "a\nX\nc\n",
+		},
+		{
+			name: "insertion and deletion",
+			base: "a\nb\nc\nd\n", ours: "a\nnew\nb\nc\nd\n", theirs: "a\nb\nc\n",
+			want: "a\nnew\nb\nc\n",
+		},
+		{
+			name: "conflicting change",
+			base: "a\nb\nc\n", ours: "a\nours\nc\n", theirs: "a\ntheirs\nc\n",
+			want:      "a\n" + markerOurs + "ours\n" + markerBase + "b\n" + markerSep + "theirs\n" + markerTheirs + "c\n",
+			conflicts: 1,
+		},
+		{
+			name: "conflict at unterminated end",
+			base: "a\nb", ours: "a\nours", theirs: "a\ntheirs",
+			want:      "a\n" + markerOurs + "ours\n" + markerBase + "b\n" + markerSep + "theirs\n" + markerTheirs,
+			conflicts: 1,
+		},
+		{
+			name: "delete against edit",
+			base: "a\nb\nc\n", ours: "a\nc\n", theirs: "a\nB\nc\n",
+			want:      "a\n" + markerOurs + markerBase + "b\n" + markerSep + "B\n" + markerTheirs + "c\n",
+			conflicts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Merge3(tt.base, tt.ours, tt.theirs)
+			if got.Text != tt.want {
+				t.Errorf("text = %q, want %q", got.Text, tt.want)
+			}
+			if got.Conflicts != tt.conflicts {
+				t.Errorf("conflicts = %d, want %d", got.Conflicts, tt.conflicts)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"codetracker-hooks/internal/config"
+	"codetracker-hooks/internal/diff"
+	"codetracker-hooks/internal/gitignore"
+)
+
+// Rule types
+const (
+	// TypePath blocks when a change touches a path matching one of Paths
+	TypePath = "path"
+	// TypeMaxFiles blocks when more than MaxFiles files changed
+	TypeMaxFiles = "max_files"
+	// TypeSecret blocks when a secret pattern matches changed content or the prompt
+	TypeSecret = "secret"
+)
+
+// Hook events policies are evaluated on
+const (
+	EventUserPromptSubmit = "UserPromptSubmit"
+	EventStop             = "Stop"
+)
+
+// detector is a named secret pattern
+type detector struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// secretDetectors are the built-in secret detectors used by secret rules
+var secretDetectors = []detector{
+	{"private key", regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP )?PRIVATE KEY( BLOCK)?-----`)},
+	{"AWS access key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"Anthropic API key", regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{"hardcoded credential", regexp.MustCompile(`(?i)\b(?:api[_-]?key|secret|passw(?:or)?d|token)\b\s*[:=]\s*['"][^'"\s]{12,}['"]`)},
+}
+
+// Input is what a policy is evaluated against. Changes are left empty at
+// UserPromptSubmit, where they would be the developer's own edits.
+type Input struct {
+	Event   string
+	Prompt  string
+	Changes []*diff.Change
+}
+
+// Violation describes the rule that blocked a prompt or stop
+type Violation struct {
+	Rule   string
+	Reason string
+}
+
+// Evaluate checks rules applying to the input's event in order and returns
+// the first violation, or nil if every rule passes
+func Evaluate(rules []config.PolicyRule, in *Input) *Violation {
+	for i := range rules {
+		rule := &rules[i]
+		if !appliesTo(rule, in.Event) {
+			continue
+		}
+
+		var reason string
+		switch rule.Type {
+		case TypePath:
+			reason = checkPaths(rule, in.Changes)
+		case TypeMaxFiles:
+			reason = checkMaxFiles(rule, in.Changes)
+		case TypeSecret:
+			reason = checkSecrets(rule, in.Prompt, in.Changes)
+		}
+
+		if reason != "" {
+			if rule.Message != "" {
+				reason = rule.Message + " (" + reason + ")"
+			}
+			return &Violation{Rule: ruleName(rule, i), Reason: reason}
+		}
+	}
+
+	return nil
+}
+
+// Applies reports whether any rule is evaluated for the given hook event
+func Applies(rules []config.PolicyRule, event string) bool {
+	for i := range rules {
+		if appliesTo(&rules[i], event) {
+			return true
+		}
+	}
+	return false
+}
+
+// appliesTo reports whether a rule is evaluated for the given hook event.
+// File rules only look at changes, so they never apply to UserPromptSubmit
+// and default to Stop; secret rules default to both events.
+func appliesTo(rule *config.PolicyRule, event string) bool {
+	fileRule := rule.Type == TypePath || rule.Type == TypeMaxFiles
+	if fileRule && event == EventUserPromptSubmit {
+		return false
+	}
+	if len(rule.Events) == 0 {
+		return !fileRule || event == EventStop
+	}
+	for _, e := range rule.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleName returns the rule's name, or a positional name if unnamed
+func ruleName(rule *config.PolicyRule, index int) string {
+	if rule.Name != "" {
+		return rule.Name
+	}
+	return fmt.Sprintf("policies[%d]", index)
+}
+
+// checkPaths reports changed files matching the rule's path patterns
+func checkPaths(rule *config.PolicyRule, changes []*diff.Change) string {
+	matcher, err := gitignore.NewMatcher(rule.Paths)
+	if err != nil {
+		return ""
+	}
+
+	var matched []string
+	for _, change := range changes {
+		parts := strings.Split(change.FilePath, "/")
+		if matcher.ShouldIgnore(change.FilePath, parts[len(parts)-1]) {
+			matched = append(matched, change.FilePath)
+		}
+	}
+	if len(matched) == 0 {
+		return ""
+	}
+	return "changed protected files: " + strings.Join(matched, ", ")
+}
+
+// checkMaxFiles reports when the change set is larger than allowed
+func checkMaxFiles(rule *config.PolicyRule, changes []*diff.Change) string {
+	if rule.MaxFiles <= 0 || len(changes) <= rule.MaxFiles {
+		return ""
+	}
+	return fmt.Sprintf("%d files changed, limit is %d", len(changes), rule.MaxFiles)
+}
+
+// checkSecrets runs built-in and custom secret detectors over the prompt and changed content
+func checkSecrets(rule *config.PolicyRule, prompt string, changes []*diff.Change) string {
+	detectors := append([]detector(nil), secretDetectors...)
+	for _, pattern := range rule.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			continue
+		}
+		detectors = append(detectors, detector{"match for " + pattern, re})
+	}
+
+	if name := detect(detectors, prompt); name != "" {
+		return "possible " + name + " in prompt"
+	}
+	for _, change := range changes {
+		if change.Type == diff.Deleted {
+			continue
+		}
+		if name := detect(detectors, change.Content); name != "" {
+			return "possible " + name + " in " + change.FilePath
+		}
+	}
+	return ""
+}
+
+// detect returns the name of the first detector matching text
+func detect(detectors []detector, text string) string {
+	if text == "" {
+		return ""
+	}
+	for _, d := range detectors {
+		if d.re.MatchString(text) {
+			return d.name
+		}
+	}
+	return ""
+}
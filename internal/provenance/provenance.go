@@ -0,0 +1,226 @@
+package provenance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"sort"
+	"strings"
+
+	"codetracker-hooks/internal/cache"
+	"codetracker-hooks/internal/diff"
+	"codetracker-hooks/internal/git"
+	"codetracker-hooks/internal/merge"
+)
+
+// FileChange is one file changed by a commit (or staged for one), with the
+// path relative to the project root
+type FileChange struct {
+	Path    string
+	Before  string
+	After   string
+	Deleted bool
+	// Hash is the SHA256 of After, comparable with diff.Change.Hash
+	Hash string
+}
+
+// Link is the overlap between a commit and one recorded interaction
+type Link struct {
+	SnapshotID    string
+	PreSnapshotID string
+	Files         []string
+	// AssistantLines counts lines added by the commit that the interaction added
+	AssistantLines int
+	// FileOverlap and LineOverlap are percentages of the commit's files and added lines
+	FileOverlap float64
+	LineOverlap float64
+}
+
+// Result summarizes which parts of a commit came from recorded interactions
+type Result struct {
+	Files      int
+	LinesAdded int
+	// AssistantLines counts added lines matching any interaction, without double counting
+	AssistantLines int
+	Links          []Link
+}
+
+// AssistantRatio returns the percentage of added lines that came from interactions
+func (r *Result) AssistantRatio() float64 {
+	return percent(r.AssistantLines, r.LinesAdded)
+}
+
+// Changes compares two file sets from git and returns the tracked files that
+// differ. prefix is the project root relative to the work tree ("" or "dir/").
+func Changes(repo *git.Repo, before, after map[string]git.TreeEntry, prefix string, track func(path string) bool) ([]FileChange, error) {
+	paths := make(map[string]bool)
+	for path, entry := range after {
+		if old, ok := before[path]; !ok || old.SHA != entry.SHA {
+			paths[path] = true
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			paths[path] = true
+		}
+	}
+
+	var changes []FileChange
+	for path := range paths {
+		rel, ok := strings.CutPrefix(path, prefix)
+		if !ok || !track(rel) {
+			continue
+		}
+
+		change := FileChange{Path: rel}
+		var err error
+		if entry, ok := before[path]; ok {
+			if change.Before, err = readBlob(repo, entry); err != nil {
+				return nil, err
+			}
+		}
+		if entry, ok := after[path]; ok {
+			if change.After, err = readBlob(repo, entry); err != nil {
+				return nil, err
+			}
+			sum := sha256.Sum256([]byte(change.After))
+			change.Hash = hex.EncodeToString(sum[:])
+		} else {
+			change.Deleted = true
+		}
+		changes = append(changes, change)
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// readBlob returns the content of a blob entry; submodules have none
+func readBlob(repo *git.Repo, entry git.TreeEntry) (string, error) {
+	if entry.Mode == git.ModeGitlink {
+		return "", nil
+	}
+	_, data, err := repo.ReadObject(entry.SHA)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Analyze matches the lines added by changes against the lines the assistant
+// added in each interaction, whose file contents are read from the blob store.
+// Changes tagged human (made by the developer before a tool ran) are not
+// counted; unattributed ones are, since without tool or conversation tracking
+// every change of an interaction is unattributed.
+func Analyze(changes []FileChange, records []*cache.InteractionRecord, objectsDir string) *Result {
+	result := &Result{Files: len(changes)}
+
+	byPath := make(map[string]*FileChange, len(changes))
+	added := make(map[string][]string, len(changes))
+	for i := range changes {
+		c := &changes[i]
+		byPath[c.Path] = c
+		added[c.Path] = addedLines(c.Before, c.After)
+		result.LinesAdded += len(added[c.Path])
+	}
+
+	// All assistant lines per file, across interactions
+	assistantByPath := make(map[string][]string)
+
+	for _, record := range records {
+		link := Link{SnapshotID: record.SnapshotID, PreSnapshotID: record.PreSnapshotID}
+
+		for _, change := range record.Changes {
+			if change.Source == diff.SourceHuman {
+				continue
+			}
+			committed, ok := byPath[change.FilePath]
+			if !ok {
+				continue
+			}
+
+			lines := interactionLines(change, objectsDir)
+			n := countOverlap(added[change.FilePath], lines)
+			sameContent := committed.Deleted && change.Type == diff.Deleted ||
+				!committed.Deleted && change.Hash != "" && change.Hash == committed.Hash
+			if n == 0 && !sameContent {
+				continue
+			}
+
+			link.Files = append(link.Files, change.FilePath)
+			link.AssistantLines += n
+			assistantByPath[change.FilePath] = append(assistantByPath[change.FilePath], lines...)
+		}
+
+		if len(link.Files) == 0 {
+			continue
+		}
+		link.FileOverlap = percent(len(link.Files), result.Files)
+		link.LineOverlap = percent(link.AssistantLines, result.LinesAdded)
+		result.Links = append(result.Links, link)
+	}
+
+	for path, lines := range assistantByPath {
+		result.AssistantLines += countOverlap(added[path], lines)
+	}
+
+	return result
+}
+
+// interactionLines returns the lines an interaction added to a file, using
+// the blobs saved before and after it
+func interactionLines(change *diff.Change, objectsDir string) []string {
+	if change.Type == diff.Deleted || change.Hash == "" {
+		return nil
+	}
+	after, err := cache.LoadBlob(objectsDir, change.Hash)
+	if err != nil {
+		return nil
+	}
+
+	before := ""
+	if change.Type != diff.Added {
+		if before, err = cache.LoadBlob(objectsDir, change.PreviousHash); err != nil {
+			return nil
+		}
+	}
+	return addedLines(before, after)
+}
+
+// addedLines returns the non-blank lines of after that are not in before,
+// without line terminators
+func addedLines(before, after string) []string {
+	var lines []string
+	for _, line := range merge.AddedLines(merge.SplitLines(before), merge.SplitLines(after)) {
+		line = strings.TrimRight(line, "\r\n")
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// countOverlap counts lines of a that also occur in b, each line of b matching at most once
+func countOverlap(a, b []string) int {
+	available := make(map[string]int, len(b))
+	for _, line := range b {
+		available[line]++
+	}
+
+	n := 0
+	for _, line := range a {
+		if available[line] > 0 {
+			available[line]--
+			n++
+		}
+	}
+	return n
+}
+
+// percent returns part/total as a percentage rounded to one decimal
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*1000/float64(total)) / 10
+}
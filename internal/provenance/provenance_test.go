@@ -0,0 +1,65 @@
+package provenance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"codetracker-hooks/internal/cache"
+	"codetracker-hooks/internal/diff"
+	"codetracker-hooks/internal/scanner"
+)
+
+func TestAnalyzeSources(t *testing.T) {
+	const (
+		before = "one\n"
+		after  = "one\ntwo\nthree\n"
+	)
+	hash := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+
+	objectsDir := t.TempDir()
+	if err := cache.SaveBlobs(objectsDir, map[string]*scanner.FileInfo{
+		"before": {Hash: hash(before), Content: before},
+		"after":  {Hash: hash(after), Content: after},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	committed := []FileChange{{Path: "a.txt", Before: before, After: after, Hash: hash(after)}}
+
+	tests := []struct {
+		source string
+		linked bool
+	}{
+		{"Edit", true},
+		{diff.SourceSubagent, true},
+		{diff.SourceUnattributed, true},
+		{diff.SourceHuman, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			record := &cache.InteractionRecord{
+				SnapshotID:    "post",
+				PreSnapshotID: "pre",
+				Changes: []*diff.Change{{
+					FilePath: "a.txt", Type: diff.Modified, Hash: hash(after), PreviousHash: hash(before), Source: tt.source,
+				}},
+			}
+
+			result := Analyze(committed, []*cache.InteractionRecord{record}, objectsDir)
+			if linked := len(result.Links) == 1; linked != tt.linked {
+				t.Fatalf("linked = %v, want %v", linked, tt.linked)
+			}
+			wantLines := 0
+			if tt.linked {
+				wantLines = 2
+			}
+			if result.LinesAdded != 2 || result.AssistantLines != wantLines {
+				t.Errorf("lines %d/%d, want %d/2", result.AssistantLines, result.LinesAdded, wantLines)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package revert
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"codetracker-hooks/internal/cache"
+	"codetracker-hooks/internal/diff"
+	"codetracker-hooks/internal/merge"
+)
+
+// Status describes what happened to a file during revert
+type Status string
+
+const (
+	Reverted        Status = "reverted"
+	Merged          Status = "merged"
+	AlreadyReverted Status = "already-reverted"
+	Conflict        Status = "conflict"
+	Failed          Status = "failed"
+)
+
+// FileResult is the revert outcome for a single file
+type FileResult struct {
+	FilePath  string
+	Status    Status
+	Conflicts int
+	Message   string
+}
+
+// Options controls how a revert is applied
+type Options struct {
+	ProjectRoot string
+	ObjectsDir  string
+	DryRun      bool
+}
+
+// Interaction applies the inverse of an interaction's changes onto the
+// current working tree using a three-way merge per file
+func Interaction(record *cache.InteractionRecord, opts Options) []*FileResult {
+	results := make([]*FileResult, 0, len(record.Changes))
+	for _, change := range record.Changes {
+		results = append(results, revertChange(change, opts))
+	}
+	return results
+}
+
+// revertChange reverts a single recorded change
+func revertChange(change *diff.Change, opts Options) *FileResult {
+	result := &FileResult{FilePath: change.FilePath}
+	absPath := filepath.Join(opts.ProjectRoot, filepath.FromSlash(change.FilePath))
+
+	current, exists, err := readCurrent(absPath)
+	if err != nil {
+		return fail(result, err)
+	}
+
+	switch change.Type {
+	case diff.Added:
+		// Inverse of an addition is a deletion, unless the file was edited since
+		if !exists {
+			result.Status = AlreadyReverted
+			return result
+		}
+		post, err := cache.LoadBlob(opts.ObjectsDir, change.Hash)
+		if err != nil {
+			return fail(result, fmt.Errorf("missing post-interaction content: %w", err))
+		}
+		if current != post {
+			result.Status = Conflict
+			result.Message = "file was modified after it was added"
+			return result
+		}
+		if !opts.DryRun {
+			if err := os.Remove(absPath); err != nil {
+				return fail(result, err)
+			}
+		}
+		result.Status = Reverted
+		return result
+
+	case diff.Deleted:
+		// Inverse of a deletion is restoring the previous content
+		pre, err := cache.LoadBlob(opts.ObjectsDir, change.PreviousHash)
+		if err != nil {
+			return fail(result, fmt.Errorf("missing pre-interaction content: %w", err))
+		}
+		if exists {
+			if current == pre {
+				result.Status = AlreadyReverted
+			} else {
+				result.Status = Conflict
+				result.Message = "file was recreated after it was deleted"
+			}
+			return result
+		}
+		if !opts.DryRun {
+			if err := writeFile(absPath, pre); err != nil {
+				return fail(result, err)
+			}
+		}
+		result.Status = Reverted
+		return result
+
+	case diff.Modified:
+		pre, err := cache.LoadBlob(opts.ObjectsDir, change.PreviousHash)
+		if err != nil {
+			return fail(result, fmt.Errorf("missing pre-interaction content: %w", err))
+		}
+		post, err := cache.LoadBlob(opts.ObjectsDir, change.Hash)
+		if err != nil {
+			return fail(result, fmt.Errorf("missing post-interaction content: %w", err))
+		}
+		if !exists {
+			result.Status = Conflict
+			result.Message = "file was deleted after it was modified"
+			return result
+		}
+		if current == pre {
+			result.Status = AlreadyReverted
+			return result
+		}
+
+		merged := merge.Merge3(post, current, pre)
+		switch {
+		case merged.Conflicts > 0:
+			result.Status = Conflict
+			result.Conflicts = merged.Conflicts
+		case current == post:
+			result.Status = Reverted
+		default:
+			result.Status = Merged
+		}
+		if !opts.DryRun {
+			if err := writeFile(absPath, merged.Text); err != nil {
+				return fail(result, err)
+			}
+		}
+		return result
+	}
+
+	return fail(result, fmt.Errorf("unknown change type %q", change.Type))
+}
+
+// readCurrent reads the working tree content of a file
+func readCurrent(absPath string) (string, bool, error) {
+	data, err := os.ReadFile(absPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return string(data), true, nil
+}
+
+// writeFile writes content, preserving the file mode when the file exists
+func writeFile(absPath, content string) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(absPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(absPath), 0755); err != nil {
+		return err
+	}
+	if data, err := os.ReadFile(absPath); err == nil && bytes.Equal(data, []byte(content)) {
+		return nil
+	}
+	return os.WriteFile(absPath, []byte(content), mode)
+}
+
+// fail marks a result as failed with the given error
+func fail(result *FileResult, err error) *FileResult {
+	result.Status = Failed
+	result.Message = err.Error()
+	return result
+}
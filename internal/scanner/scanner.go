@@ -72,6 +72,21 @@ func (s *Scanner) shouldTrackFile(absPath string) bool {
 	return s.trackExtensions[ext]
 }
 
+// Tracks reports whether a slash-separated path relative to the project root
+// would be tracked by Scan, without checking that the file exists
+func (s *Scanner) Tracks(relativePath string) bool {
+	// Scan skips ignored directories entirely
+	dir := s.projectRoot
+	parts := strings.Split(relativePath, "/")
+	for _, part := range parts[:len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		if s.shouldIgnorePath(dir) {
+			return false
+		}
+	}
+	return s.shouldTrackFile(filepath.Join(s.projectRoot, filepath.FromSlash(relativePath)))
+}
+
 // calculateHash computes SHA256 hash of content
 func calculateHash(content []byte) string {
 	hash := sha256.Sum256(content)
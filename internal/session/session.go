@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+
+	"codetracker-hooks/internal/git"
 )
 
 // SessionData holds the session state between hooks
@@ -12,6 +14,17 @@ type SessionData struct {
 	Prompt          string `json:"prompt"`
 	ClaudeSessionID string `json:"claude_session_id"`
 	StartedAt       string `json:"started_at"`
+
+	// Labels and Ticket classify the prompt, for every record made during it
+	Labels []string `json:"labels,omitempty"`
+	Ticket string   `json:"ticket,omitempty"`
+
+	// Git is the repository state when the prompt started, reused by the
+	// tool and subagent hooks instead of reading the repository again
+	Git *git.Metadata `json:"git,omitempty"`
+
+	// ProjectPreSnapshotIDs holds the pre snapshot ID of each secondary project, by project hash
+	ProjectPreSnapshotIDs map[string]string `json:"project_pre_snapshot_ids,omitempty"`
 }
 
 // Save saves session data to file
@@ -0,0 +1,75 @@
+package tagging
+
+import (
+	"regexp"
+	"strings"
+
+	"codetracker-hooks/internal/config"
+)
+
+// Result is the classification of a prompt
+type Result struct {
+	Labels []string
+	Ticket string
+}
+
+// Classify returns the labels of the rules matching a prompt, in rule order
+// and without duplicates, and the ticket ID found by the ticket pattern.
+// cfg must have been compiled with Tagging.Compile, as loaded configs are.
+func Classify(cfg config.Tagging, prompt string) Result {
+	var result Result
+	seen := make(map[string]bool)
+	for i := range cfg.Rules {
+		rule := &cfg.Rules[i]
+		if rule.Label == "" || seen[rule.Label] || !matches(rule, prompt) {
+			continue
+		}
+		seen[rule.Label] = true
+		result.Labels = append(result.Labels, rule.Label)
+	}
+
+	result.Ticket = ticket(cfg.Ticket, prompt)
+	return result
+}
+
+// matches reports whether any of a rule's commands, keywords or patterns match the prompt
+func matches(rule *config.TagRule, prompt string) bool {
+	for _, command := range rule.Commands {
+		if isCommand(prompt, command) {
+			return true
+		}
+	}
+	for _, re := range rule.Regexps {
+		if re.MatchString(prompt) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCommand reports whether the prompt invokes a slash command, with or
+// without arguments. The leading slash of command is optional.
+func isCommand(prompt, command string) bool {
+	command = "/" + strings.TrimPrefix(command, "/")
+	if command == "/" {
+		return false
+	}
+	rest, ok := strings.CutPrefix(strings.TrimSpace(prompt), command)
+	return ok && (rest == "" || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\n')
+}
+
+// ticket returns the first match of re in the prompt, or its first
+// capture group if it has one
+func ticket(re *regexp.Regexp, prompt string) string {
+	if re == nil {
+		return ""
+	}
+	m := re.FindStringSubmatch(prompt)
+	if m == nil {
+		return ""
+	}
+	if len(m) > 1 {
+		return m[1]
+	}
+	return m[0]
+}
@@ -0,0 +1,88 @@
+package tagging
+
+import (
+	"strings"
+	"testing"
+
+	"codetracker-hooks/internal/config"
+)
+
+func TestClassify(t *testing.T) {
+	cfg := config.Tagging{Rules: []config.TagRule{
+		{Label: "bugfix", Keywords: []string{"fix", "bug"}},
+		{Label: "refactor", Patterns: []string{`clean\s*up`}, Keywords: []string{"refactor"}},
+		{Label: "test", Commands: []string{"/test"}, Keywords: []string{"test"}},
+		{Label: "bugfix", Keywords: []string{"crash"}},
+	}}
+	cfg.Compile()
+
+	tests := []struct {
+		prompt string
+		want   []string
+	}{
+		{"Fix the login form", []string{"bugfix"}},
+		{"add a prefix to ids", nil},
+		{"bug: crash on save", []string{"bugfix"}},
+		{"please CLEANUP utils.go", []string{"refactor"}},
+		{"refactor and fix (bug)", []string{"bugfix", "refactor"}},
+		{"/test ./...", []string{"test"}},
+		{"/tester", nil},
+		{"write a test.", []string{"test"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prompt, func(t *testing.T) {
+			got := Classify(cfg, tt.prompt).Labels
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("labels %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTicket(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		prompt  string
+		want    string
+	}{
+		{"whole match", `[A-Z]+-\d+`, "fix PROJ-123 today", "PROJ-123"},
+		{"capture group", `#(\d+)`, "see #42 and #43", "42"},
+		{"no match", `[A-Z]+-\d+`, "no ticket here", ""},
+		{"no pattern", "", "PROJ-123", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Tagging{TicketPattern: tt.pattern}
+			cfg.Compile()
+			if got := Classify(cfg, tt.prompt).Ticket; got != tt.want {
+				t.Errorf("ticket %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidPatterns(t *testing.T) {
+	cfg := &config.Config{Tagging: config.Tagging{
+		Rules:         []config.TagRule{{Label: "broken", Patterns: []string{"(", "ok"}}},
+		TicketPattern: "[",
+	}}
+	cfg.Tagging.Compile()
+
+	result := Classify(cfg.Tagging, "ok [ (")
+	if strings.Join(result.Labels, ",") != "broken" || result.Ticket != "" {
+		t.Errorf("result %+v, want label from the valid pattern and no ticket", result)
+	}
+
+	reported := map[string]bool{}
+	for _, issue := range config.Check(cfg, config.Sources{}) {
+		if issue.Severity == config.SeverityError && strings.HasPrefix(issue.Key, "tagging.") {
+			reported[issue.Key] = true
+		}
+	}
+	if len(reported) != 2 || !reported["tagging.rules[0].patterns[0]"] || !reported["tagging.ticket_pattern"] {
+		t.Errorf("reported %v, want patterns[0] and ticket_pattern", reported)
+	}
+}
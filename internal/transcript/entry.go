@@ -0,0 +1,146 @@
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Entry is one line of the Claude Code transcript JSONL file
+type Entry struct {
+	Type        string   `json:"type"`
+	UUID        string   `json:"uuid"`
+	ParentUUID  string   `json:"parentUuid"`
+	Timestamp   string   `json:"timestamp"`
+	IsSidechain bool     `json:"isSidechain"`
+	Message     *Message `json:"message"`
+}
+
+// Message is the API message of a user or assistant entry
+type Message struct {
+	ID         string  `json:"id"`
+	Role       string  `json:"role"`
+	Model      string  `json:"model"`
+	Content    Content `json:"content"`
+	StopReason string  `json:"stop_reason"`
+	Usage      *Usage  `json:"usage"`
+}
+
+// Usage is the token usage of an assistant message. Every entry of a message
+// split over several lines repeats it.
+type Usage struct {
+	InputTokens              int64 `json:"input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+}
+
+// Content is message or tool result content: either a plain string
+// (Text) or a list of content blocks
+type Content struct {
+	Text   string
+	Blocks []Block
+}
+
+// UnmarshalJSON accepts a string, an array of content blocks or a single
+// block object. Blocks that fail to decode are skipped rather than failing
+// the whole entry; content of any other shape is left empty.
+func (c *Content) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+	switch data[0] {
+	case '"':
+		return json.Unmarshal(data, &c.Text)
+	case '{':
+		var block Block
+		if json.Unmarshal(data, &block) == nil {
+			c.Blocks = []Block{block}
+		}
+	case '[':
+		var raw []json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		c.Blocks = make([]Block, 0, len(raw))
+		for _, item := range raw {
+			var block Block
+			if json.Unmarshal(item, &block) == nil {
+				c.Blocks = append(c.Blocks, block)
+			}
+		}
+	}
+	return nil
+}
+
+// Block is one item of message content. Bare strings, used by older
+// transcripts, become blocks with an empty Type.
+type Block struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+
+	// thinking
+	Thinking string `json:"thinking"`
+
+	// tool_use
+	ID    string                 `json:"id"`
+	Name  string                 `json:"name"`
+	Input map[string]interface{} `json:"input"`
+
+	// tool_result
+	ToolUseID string  `json:"tool_use_id"`
+	Content   Content `json:"content"`
+	IsError   bool    `json:"is_error"`
+
+	// image, document
+	Source *Source `json:"source"`
+}
+
+// Source is the data of an image or document block
+type Source struct {
+	Type      string `json:"type"` // base64, text or url
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+	URL       string `json:"url"`
+}
+
+// Size returns the size in bytes of the inline data, or 0 for a URL source
+func (s *Source) Size() int {
+	switch s.Type {
+	case "base64":
+		n := len(s.Data) * 3 / 4
+		return n - (len(s.Data) - len(strings.TrimRight(s.Data, "=")))
+	case "text":
+		return len(s.Data)
+	}
+	return 0
+}
+
+// UnmarshalJSON accepts a content block object or a bare string. A tool_use
+// input that is not an object is left nil instead of rejecting the block.
+func (b *Block) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		*b = Block{}
+		return json.Unmarshal(data, &b.Text)
+	}
+	type plain Block
+	aux := struct {
+		*plain
+		Input json.RawMessage `json:"input"`
+	}{plain: (*plain)(b)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	b.Input = nil
+	json.Unmarshal(aux.Input, &b.Input)
+	return nil
+}
+
+// Blocks returns the entry's content blocks, or nil for plain-string content
+func (e *Entry) Blocks() []Block {
+	if e.Message == nil {
+		return nil
+	}
+	return e.Message.Content.Blocks
+}
@@ -0,0 +1,69 @@
+package transcript
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEntryContentShapes(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string // block types
+		text    string
+	}{
+		{"string", `"hello"`, nil, "hello"},
+		{"blocks", `[{"type":"text","text":"a"},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"ls"}}]`, []string{"text", "tool_use"}, ""},
+		{"bare strings", `["a","b"]`, []string{"", ""}, ""},
+		{"tool_use input not an object", `[{"type":"tool_use","id":"t1","name":"Bash","input":"ls"}]`, []string{"tool_use"}, ""},
+		{"tool_result content object", `[{"type":"tool_result","tool_use_id":"t1","content":{"type":"text","text":"out"}}]`, []string{"tool_result"}, ""},
+		{"undecodable block skipped", `[{"type":"text","text":5},{"type":"text","text":"kept"}]`, []string{"text"}, ""},
+		{"single block object", `{"type":"text","text":"a"}`, []string{"text"}, ""},
+		{"number", `42`, nil, ""},
+		{"null", `null`, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := `{"type":"user","uuid":"u1","message":{"role":"user","content":` + tt.content + `}}`
+			var entry Entry
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatalf("entry rejected: %v", err)
+			}
+			content := entry.Message.Content
+			if content.Text != tt.text {
+				t.Errorf("text = %q, want %q", content.Text, tt.text)
+			}
+			if len(content.Blocks) != len(tt.want) {
+				t.Fatalf("%d blocks, want %d", len(content.Blocks), len(tt.want))
+			}
+			for i, block := range content.Blocks {
+				if block.Type != tt.want[i] {
+					t.Errorf("block %d type %q, want %q", i, block.Type, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBlockFields(t *testing.T) {
+	var content Content
+	data := `[{"type":"tool_use","id":"t1","name":"Edit","input":{"file_path":"a.go"}},` +
+		`{"type":"tool_use","id":"t2","name":"Bash","input":["ls"]},` +
+		`{"type":"tool_result","tool_use_id":"t1","content":{"type":"text","text":"done"}}]`
+	if err := json.Unmarshal([]byte(data), &content); err != nil {
+		t.Fatal(err)
+	}
+	if len(content.Blocks) != 3 {
+		t.Fatalf("%d blocks, want 3", len(content.Blocks))
+	}
+	if path, _ := content.Blocks[0].Input["file_path"].(string); path != "a.go" {
+		t.Errorf("file_path = %q, want a.go", path)
+	}
+	if b := content.Blocks[1]; b.ID != "t2" || b.Input != nil {
+		t.Errorf("tool_use with list input = %+v, want ID t2 and no input", b)
+	}
+	if inner := content.Blocks[2].Content.Blocks; len(inner) != 1 || inner[0].Text != "done" {
+		t.Errorf("tool_result content = %+v, want one text block", inner)
+	}
+}
@@ -0,0 +1,47 @@
+package transcript
+
+// maxRecordedErrors caps how many malformed lines Health describes
+const maxRecordedErrors = 20
+
+// ParseError is a transcript line that is not a valid JSON entry
+type ParseError struct {
+	Offset int64
+	Line   int // 1-based, counted from where reading started
+	Err    string
+}
+
+// Health counts the transcript lines a Reader consumed and the malformed ones
+// among them; malformed lines are skipped rather than returned by Next
+type Health struct {
+	Lines     int
+	Malformed int
+	// Errors describes the first maxRecordedErrors malformed lines
+	Errors []ParseError
+	// Incomplete is set when reading stopped at a final line without a newline
+	Incomplete bool
+}
+
+// add counts a consumed line
+func (h *Health) add(l *line) {
+	h.Lines++
+	if l.entry != nil {
+		return
+	}
+	h.Malformed++
+	if len(h.Errors) < maxRecordedErrors {
+		h.Errors = append(h.Errors, ParseError{Offset: l.offset, Line: l.number, Err: l.err.Error()})
+	}
+}
+
+// Validate reads a whole transcript file and reports its health
+func Validate(path string) (Health, error) {
+	r, err := Open(path, "", nil)
+	if err != nil {
+		return Health{}, err
+	}
+	defer r.Close()
+
+	for r.Next() {
+	}
+	return r.Health(), r.Err()
+}
@@ -0,0 +1,255 @@
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+
+	"codetracker-hooks/internal/cache"
+)
+
+// recentHashCount is how many consumed entry hashes a cursor keeps for re-syncing
+const recentHashCount = 64
+
+// line is one complete, non-empty transcript line and its position
+type line struct {
+	offset int64
+	end    int64
+	number int // 1-based, counted from where reading started
+	raw    []byte
+	entry  *Entry // nil when the line is not a valid entry
+	err    error  // why the line is not a valid entry
+}
+
+// hash identifies the line's entry by its uuid, or by its content when it has none
+func (l *line) hash() string {
+	key := l.raw
+	if l.entry != nil && l.entry.UUID != "" {
+		key = []byte(l.entry.UUID)
+	}
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// lineReader streams lines of any length from a byte offset
+type lineReader struct {
+	r       *bufio.Reader
+	pos     int64
+	lines   int
+	partial bool // stopped at a final line without a newline
+}
+
+// newLineReader seeks file to offset and reads from there
+func newLineReader(file *os.File, offset int64) (*lineReader, error) {
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return &lineReader{r: bufio.NewReaderSize(file, 64*1024), pos: offset}, nil
+}
+
+// next returns the next non-empty line, or io.EOF at the end. A final line
+// without a newline is still being written and is left unread.
+func (lr *lineReader) next() (*line, error) {
+	for {
+		b, err := lr.r.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF && len(bytes.TrimSpace(b)) > 0 {
+				lr.partial = true
+			}
+			return nil, err
+		}
+
+		lr.lines++
+		l := &line{offset: lr.pos, end: lr.pos + int64(len(b)), number: lr.lines, raw: bytes.TrimSpace(b)}
+		lr.pos = l.end
+		if len(l.raw) == 0 {
+			continue
+		}
+		l.entry = &Entry{}
+		if l.err = json.Unmarshal(l.raw, l.entry); l.err != nil {
+			l.entry = nil
+		}
+		return l, nil
+	}
+}
+
+// readLines calls fn for each line starting at offset until fn returns false
+func readLines(file *os.File, offset int64, fn func(l *line) bool) error {
+	lr, err := newLineReader(file, offset)
+	if err != nil {
+		return err
+	}
+	for {
+		l, err := lr.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !fn(l) {
+			return nil
+		}
+	}
+}
+
+// advance moves the cursor past l
+func advance(cursor *cache.TranscriptState, l *line) {
+	hash := l.hash()
+	cursor.ByteOffset = l.end
+	cursor.LastEntryOffset = l.offset
+	cursor.LastEntryHash = hash
+	cursor.LastLineCount++
+	cursor.RecentHashes = append(cursor.RecentHashes, hash)
+	if len(cursor.RecentHashes) > recentHashCount {
+		cursor.RecentHashes = cursor.RecentHashes[len(cursor.RecentHashes)-recentHashCount:]
+	}
+}
+
+// start returns the cursor to resume from. A cursor whose last entry is no
+// longer where it was (the transcript was rewound, compacted or rewritten) is
+// re-synced to the last entry both have seen; with no common entry, reading
+// starts over.
+func start(file *os.File, prev *cache.TranscriptState) (*cache.TranscriptState, error) {
+	cursor := &cache.TranscriptState{}
+	if prev == nil {
+		return cursor, nil
+	}
+	cursor.SessionID = prev.SessionID
+
+	// Cursors from older versions only know how many lines were consumed
+	if prev.ByteOffset == 0 {
+		err := readLines(file, 0, func(l *line) bool {
+			if cursor.LastLineCount >= prev.LastLineCount {
+				return false
+			}
+			advance(cursor, l)
+			return true
+		})
+		return cursor, err
+	}
+
+	valid := false
+	err := readLines(file, prev.LastEntryOffset, func(l *line) bool {
+		valid = l.end == prev.ByteOffset && l.hash() == prev.LastEntryHash
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if valid {
+		*cursor = *prev
+		cursor.RecentHashes = append([]string(nil), prev.RecentHashes...)
+		return cursor, nil
+	}
+
+	// Re-sync: find the last line that the cursor had consumed
+	seen := make(map[string]bool, len(prev.RecentHashes))
+	for _, h := range prev.RecentHashes {
+		seen[h] = true
+	}
+	scan := &cache.TranscriptState{SessionID: prev.SessionID}
+	err = readLines(file, 0, func(l *line) bool {
+		advance(scan, l)
+		if seen[scan.LastEntryHash] {
+			*cursor = *scan
+			cursor.RecentHashes = append([]string(nil), scan.RecentHashes...)
+		}
+		return true
+	})
+	return cursor, err
+}
+
+// Reader iterates over the transcript entries after a saved cursor
+type Reader struct {
+	file   *os.File
+	lines  *lineReader
+	cursor *cache.TranscriptState
+	entry  *Entry
+	err    error
+	health Health
+}
+
+// Open opens a transcript and positions it after the entries prev has
+// consumed. A cursor from another session is ignored.
+func Open(path, sessionID string, prev *cache.TranscriptState) (*Reader, error) {
+	if prev != nil && prev.SessionID != sessionID {
+		prev = nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := start(file, prev)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	cursor.SessionID = sessionID
+
+	lines, err := newLineReader(file, cursor.ByteOffset)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &Reader{file: file, lines: lines, cursor: cursor}, nil
+}
+
+// Next advances to the next entry, reporting false at the end of the
+// transcript or on a read error. Malformed lines are skipped but consumed.
+func (r *Reader) Next() bool {
+	r.entry = nil
+	if r.err != nil {
+		return false
+	}
+	for {
+		l, err := r.lines.next()
+		if err != nil {
+			if err != io.EOF {
+				r.err = err
+			}
+			return false
+		}
+
+		advance(r.cursor, l)
+		r.health.add(l)
+		if l.entry != nil {
+			r.entry = l.entry
+			return true
+		}
+	}
+}
+
+// Health reports the lines read so far and the malformed ones among them
+func (r *Reader) Health() Health {
+	h := r.health
+	h.Incomplete = r.lines.partial
+	return h
+}
+
+// Entry returns the entry read by the last call to Next
+func (r *Reader) Entry() *Entry {
+	return r.entry
+}
+
+// Err returns the read error that stopped Next, if any
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Cursor returns the position after the last consumed line, to save for the next Open
+func (r *Reader) Cursor() *cache.TranscriptState {
+	return r.cursor
+}
+
+// Close closes the transcript file
+func (r *Reader) Close() error {
+	return r.file.Close()
+}
@@ -0,0 +1,191 @@
+package transcript
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"codetracker-hooks/internal/cache"
+)
+
+// lines returns one transcript line per uuid. A uuid ending in "~" is
+// written without its trailing newline, as a line still being written.
+func lines(uuids ...string) string {
+	var sb strings.Builder
+	for _, uuid := range uuids {
+		partial := strings.HasSuffix(uuid, "~")
+		uuid = strings.TrimSuffix(uuid, "~")
+		if uuid == "garbage" {
+			sb.WriteString("{not json")
+		} else {
+			fmt.Fprintf(&sb, `{"type":"user","uuid":%q,"message":{"role":"user","content":"text of %s"}}`, uuid, uuid)
+		}
+		if !partial {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// readAll reads the transcript after prev and returns the uuids of the entries read
+func readAll(t *testing.T, path, sessionID string, prev *cache.TranscriptState) ([]string, *Reader) {
+	t.Helper()
+	r, err := Open(path, sessionID, prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+
+	var uuids []string
+	for r.Next() {
+		uuids = append(uuids, r.Entry().UUID)
+	}
+	if err := r.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return uuids, r
+}
+
+func TestReaderCursor(t *testing.T) {
+	tests := []struct {
+		name string
+		// before is read first; its cursor is then used to read after
+		before    string
+		after     string
+		session   string // session of the second read, "s" when empty
+		legacy    bool   // keep only the line count of the cursor, as older versions did
+		wantFirst []string
+		want      []string
+	}{
+		{
+			name:      "appended entries",
+			before:    lines("a", "b"),
+			after:     lines("a", "b", "c", "d"),
+			wantFirst: []string{"a", "b"},
+			want:      []string{"c", "d"},
+		},
+		{
+			name:      "nothing new",
+			before:    lines("a", "b"),
+			after:     lines("a", "b"),
+			wantFirst: []string{"a", "b"},
+		},
+		{
+			name:      "partial trailing line",
+			before:    lines("a", "b~"),
+			after:     lines("a", "b", "c"),
+			wantFirst: []string{"a"},
+			want:      []string{"b", "c"},
+		},
+		{
+			name:      "malformed line is consumed",
+			before:    lines("a", "garbage", "b"),
+			after:     lines("a", "garbage", "b", "c"),
+			wantFirst: []string{"a", "b"},
+			want:      []string{"c"},
+		},
+		{
+			name:      "rewind",
+			before:    lines("a", "b", "c"),
+			after:     lines("a", "b2", "c2"),
+			wantFirst: []string{"a", "b", "c"},
+			want:      []string{"b2", "c2"},
+		},
+		{
+			name:      "compaction moves entries",
+			before:    lines("a", "b"),
+			after:     lines("summary", "a", "b", "c"),
+			wantFirst: []string{"a", "b"},
+			want:      []string{"c"},
+		},
+		{
+			name:      "same length after rewrite",
+			before:    lines("a", "b"),
+			after:     lines("a", "x"),
+			wantFirst: []string{"a", "b"},
+			want:      []string{"x"},
+		},
+		{
+			name:      "nothing in common",
+			before:    lines("a", "b"),
+			after:     lines("x", "y"),
+			wantFirst: []string{"a", "b"},
+			want:      []string{"x", "y"},
+		},
+		{
+			name:      "legacy line count cursor",
+			before:    lines("a", "b"),
+			after:     lines("a", "b", "c"),
+			legacy:    true,
+			wantFirst: []string{"a", "b"},
+			want:      []string{"c"},
+		},
+		{
+			name:      "other session starts over",
+			before:    lines("a", "b"),
+			after:     lines("a", "b", "c"),
+			session:   "other",
+			wantFirst: []string{"a", "b"},
+			want:      []string{"a", "b", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "transcript.jsonl")
+			if err := os.WriteFile(path, []byte(tt.before), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			first, r := readAll(t, path, "s", nil)
+			if strings.Join(first, ",") != strings.Join(tt.wantFirst, ",") {
+				t.Fatalf("first read %v, want %v", first, tt.wantFirst)
+			}
+			cursor := r.Cursor()
+			if complete := strings.LastIndex(tt.before, "\n") + 1; cursor.ByteOffset != int64(complete) {
+				t.Errorf("cursor at byte %d, want %d", cursor.ByteOffset, complete)
+			}
+			if incomplete := !strings.HasSuffix(tt.before, "\n"); r.Health().Incomplete != incomplete {
+				t.Errorf("Incomplete = %v, want %v", r.Health().Incomplete, incomplete)
+			}
+			if tt.legacy {
+				cursor = &cache.TranscriptState{SessionID: cursor.SessionID, LastLineCount: cursor.LastLineCount}
+			}
+
+			if err := os.WriteFile(path, []byte(tt.after), 0644); err != nil {
+				t.Fatal(err)
+			}
+			session := tt.session
+			if session == "" {
+				session = "s"
+			}
+			got, r := readAll(t, path, session, cursor)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("second read %v, want %v", got, tt.want)
+			}
+			if r.Cursor().ByteOffset != int64(len(tt.after)) {
+				t.Errorf("cursor at byte %d after second read, want %d", r.Cursor().ByteOffset, len(tt.after))
+			}
+		})
+	}
+}
+
+func TestReaderHealth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	if err := os.WriteFile(path, []byte(lines("a", "garbage", "b", "garbage", "c~")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	health, err := Validate(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.Lines != 4 || health.Malformed != 2 || !health.Incomplete {
+		t.Errorf("health = %+v, want 4 lines, 2 malformed, incomplete", health)
+	}
+	if len(health.Errors) != 2 || health.Errors[0].Line != 2 || health.Errors[1].Line != 4 {
+		t.Errorf("errors = %+v, want lines 2 and 4", health.Errors)
+	}
+}